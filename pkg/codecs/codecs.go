@@ -0,0 +1,350 @@
+package codecs
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Family groups the sample entries of a codec, e.g. hvc1 and hev1 are both HEVC
+type Family string
+
+const (
+	// FamilyAVC is H.264 video, signaled as avc1 or avc3
+	FamilyAVC Family = "avc"
+	// FamilyHEVC is H.265 video, signaled as hvc1 or hev1
+	FamilyHEVC Family = "hvc"
+	// FamilyDolbyVision is Dolby Vision video, signaled as dvh1, dvhe, dva1 or dvav
+	FamilyDolbyVision Family = "dvh"
+	// FamilyAV1 is AV1 video, signaled as av01
+	FamilyAV1 Family = "av1"
+	// FamilyVP9 is VP9 video, signaled as vp09
+	FamilyVP9 Family = "vp9"
+	// FamilyMPEG4Audio is MPEG-4 audio such as AAC, signaled as mp4a
+	FamilyMPEG4Audio Family = "mp4a"
+	// FamilyAC3 is Dolby Digital audio, signaled as ac-3 or mp4a.a5
+	FamilyAC3 Family = "ac-3"
+	// FamilyEAC3 is Dolby Digital Plus audio, signaled as ec-3 or mp4a.a6
+	FamilyEAC3 Family = "ec-3"
+	// FamilyAC4 is Dolby AC-4 audio, signaled as ac-4
+	FamilyAC4 Family = "ac-4"
+	// FamilyOpus is Opus audio, signaled as Opus
+	FamilyOpus Family = "opus"
+	// FamilyFLAC is FLAC audio, signaled as fLaC
+	FamilyFLAC Family = "flac"
+	// FamilyTTML is TTML captions carried in ISOBMFF, signaled as stpp
+	FamilyTTML Family = "stpp"
+	// FamilyWebVTT is WebVTT captions carried in ISOBMFF, signaled as wvtt
+	FamilyWebVTT Family = "wvtt"
+)
+
+var sampleEntryFamilies = map[string]Family{
+	"avc1": FamilyAVC,
+	"avc3": FamilyAVC,
+	"hvc1": FamilyHEVC,
+	"hev1": FamilyHEVC,
+	"dvh1": FamilyDolbyVision,
+	"dvhe": FamilyDolbyVision,
+	"dva1": FamilyDolbyVision,
+	"dvav": FamilyDolbyVision,
+	"av01": FamilyAV1,
+	"vp09": FamilyVP9,
+	"mp4a": FamilyMPEG4Audio,
+	"ac-3": FamilyAC3,
+	"ec-3": FamilyEAC3,
+	"ac-4": FamilyAC4,
+	"opus": FamilyOpus,
+	"flac": FamilyFLAC,
+	"stpp": FamilyTTML,
+	"wvtt": FamilyWebVTT,
+}
+
+// aliases are the friendly names filters accept for codecs, along with the families or
+// codec strings each of them stands for
+var aliases = map[string][]string{
+	"h264":        {string(FamilyAVC)},
+	"hevc":        {string(FamilyHEVC)},
+	"h265":        {string(FamilyHEVC)},
+	"hdr10":       {"hev1.2", "hvc1.2"},
+	"dolbyvision": {string(FamilyDolbyVision)},
+	"dovi":        {string(FamilyDolbyVision)},
+	"av01":        {string(FamilyAV1)},
+	"vp09":        {string(FamilyVP9)},
+	"aac":         {string(FamilyMPEG4Audio)},
+	"ac3":         {string(FamilyAC3)},
+	"eac3":        {string(FamilyEAC3)},
+	"ac4":         {string(FamilyAC4)},
+	"ttml":        {string(FamilyTTML)},
+	"webvtt":      {string(FamilyWebVTT)},
+}
+
+var avcProfiles = map[int]string{
+	66:  "baseline",
+	77:  "main",
+	88:  "extended",
+	100: "high",
+	110: "high10",
+	122: "high422",
+	244: "high444",
+}
+
+var hevcProfiles = map[int]string{
+	1: "main",
+	2: "main10",
+	3: "mainstill",
+	4: "rext",
+}
+
+var av1Profiles = map[int]string{
+	0: "main",
+	1: "high",
+	2: "professional",
+}
+
+var mpeg4AudioProfiles = map[string]string{
+	"1":  "main",
+	"2":  "lc",
+	"5":  "he",
+	"29": "hev2",
+	"34": "mp3",
+}
+
+// Codec is an RFC 6381 codec string broken down into the parts filters care about
+type Codec struct {
+	// SampleEntry is the four character code the codec string starts with, e.g. hvc1
+	SampleEntry string
+	Family      Family
+	// Profile is the name of the profile when known (e.g. high, main10 or lc), or
+	// the profile number as signaled otherwise
+	Profile string
+	// Tier is the HEVC or AV1 tier, main or high
+	Tier string
+	// Level is the level as a decimal number, e.g. 3.1 for avc1.64001f and
+	// 5.1 for hvc1.2.4.L153.B0
+	Level float64
+}
+
+// Parse breaks down an RFC 6381 codec string. Parts that are missing or can't be
+// parsed are left empty, so unknown codecs only carry their sample entry and family
+func Parse(codec string) Codec {
+	parts := strings.Split(strings.TrimSpace(codec), ".")
+
+	c := Codec{SampleEntry: strings.ToLower(parts[0])}
+	c.Family = sampleEntryFamilies[c.SampleEntry]
+	if c.Family == "" {
+		c.Family = Family(c.SampleEntry)
+	}
+
+	switch c.Family {
+	case FamilyAVC:
+		c.parseAVC(parts[1:])
+	case FamilyHEVC:
+		c.parseHEVC(parts[1:])
+	case FamilyDolbyVision:
+		c.parseDolbyVision(parts[1:])
+	case FamilyAV1:
+		c.parseAV1(parts[1:])
+	case FamilyVP9:
+		c.parseVP9(parts[1:])
+	case FamilyMPEG4Audio:
+		c.parseMPEG4Audio(parts[1:])
+	}
+
+	return c
+}
+
+// Expand returns the families or codec strings an alias stands for, e.g. hdr10 expands to
+// hev1.2 and hvc1.2. Values that aren't aliases are returned as they are
+func Expand(name string) ([]string, bool) {
+	if expanded, found := aliases[strings.ToLower(name)]; found {
+		return expanded, true
+	}
+
+	return []string{name}, false
+}
+
+// ParseFamily returns the family a filter value or alias refers to, if any
+func ParseFamily(name string) (Family, bool) {
+	expanded, _ := Expand(name)
+	if len(expanded) != 1 {
+		return "", false
+	}
+
+	family := Family(strings.ToLower(expanded[0]))
+	for _, f := range sampleEntryFamilies {
+		if f == family {
+			return family, true
+		}
+	}
+
+	return "", false
+}
+
+// Matches returns true if the codec string belongs to the family named by filter, or when
+// filter is a codec string itself, if it shares the family of codec and the parts filter declares
+// (e.g. hvc1.2 matches both hvc1.2.4.L153.B0 and hev1.2.4.L120.B0)
+func Matches(codec, filter string) bool {
+	c := Parse(codec)
+	if family, found := ParseFamily(filter); found {
+		return c.Family == family
+	}
+
+	f := Parse(filter)
+	if c.Family != f.Family {
+		return false
+	}
+
+	codecParts := strings.Split(strings.ToLower(strings.TrimSpace(codec)), ".")
+	filterParts := strings.Split(strings.ToLower(strings.TrimSpace(filter)), ".")
+	if len(filterParts) > len(codecParts) {
+		return false
+	}
+
+	for i := 1; i < len(filterParts); i++ {
+		if filterParts[i] != codecParts[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseAVC reads avc1.PPCCLL, or the legacy avc1.PP.LL form using decimal numbers
+func (c *Codec) parseAVC(parts []string) {
+	var profile, level int64
+	var err error
+	switch {
+	case len(parts) == 1 && len(parts[0]) == 6:
+		if profile, err = strconv.ParseInt(parts[0][:2], 16, 64); err != nil {
+			return
+		}
+		if level, err = strconv.ParseInt(parts[0][4:], 16, 64); err != nil {
+			return
+		}
+	case len(parts) == 2:
+		if profile, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+			return
+		}
+		if level, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return
+		}
+	default:
+		return
+	}
+
+	c.Profile = profileName(avcProfiles, int(profile))
+	c.Level = float64(level) / 10
+}
+
+// parseHEVC reads hvc1.[A-C]P.C.[LH]LL.B..., where the level is 30 times the level number
+func (c *Codec) parseHEVC(parts []string) {
+	if len(parts) > 0 {
+		profile, err := strconv.Atoi(strings.TrimLeft(strings.ToUpper(parts[0]), "ABC"))
+		if err == nil {
+			c.Profile = profileName(hevcProfiles, profile)
+		}
+	}
+
+	if len(parts) > 2 && len(parts[2]) > 1 {
+		switch strings.ToUpper(parts[2][:1]) {
+		case "L":
+			c.Tier = "main"
+		case "H":
+			c.Tier = "high"
+		default:
+			return
+		}
+
+		if level, err := strconv.Atoi(parts[2][1:]); err == nil {
+			c.Level = float64(level) / 30
+			// levels are signaled with a single decimal digit, e.g. L93 is level 3.1
+			c.Level = float64(int(c.Level*10+0.5)) / 10
+		}
+	}
+}
+
+// parseDolbyVision reads dvh1.PP.LL
+func (c *Codec) parseDolbyVision(parts []string) {
+	if len(parts) > 0 {
+		if profile, err := strconv.Atoi(parts[0]); err == nil {
+			c.Profile = strconv.Itoa(profile)
+		}
+	}
+
+	if len(parts) > 1 {
+		if level, err := strconv.Atoi(parts[1]); err == nil {
+			c.Level = float64(level)
+		}
+	}
+}
+
+// parseAV1 reads av01.P.LLT.DD..., where LL is the seq_level_idx and T the tier
+func (c *Codec) parseAV1(parts []string) {
+	if len(parts) > 0 {
+		if profile, err := strconv.Atoi(parts[0]); err == nil {
+			c.Profile = profileName(av1Profiles, profile)
+		}
+	}
+
+	if len(parts) > 1 && len(parts[1]) == 3 {
+		switch strings.ToUpper(parts[1][2:]) {
+		case "M":
+			c.Tier = "main"
+		case "H":
+			c.Tier = "high"
+		default:
+			return
+		}
+
+		// seq_level_idx 0 is level 2.0, and each major level has four minor levels
+		if idx, err := strconv.Atoi(parts[1][:2]); err == nil {
+			c.Level = float64(2+idx/4) + float64(idx%4)/10
+		}
+	}
+}
+
+// parseVP9 reads vp09.PP.LL.DD..., where the level is ten times the level number
+func (c *Codec) parseVP9(parts []string) {
+	if len(parts) > 0 {
+		if profile, err := strconv.Atoi(parts[0]); err == nil {
+			c.Profile = strconv.Itoa(profile)
+		}
+	}
+
+	if len(parts) > 1 {
+		if level, err := strconv.Atoi(parts[1]); err == nil {
+			c.Level = float64(level) / 10
+		}
+	}
+}
+
+// parseMPEG4Audio reads mp4a.OO[.A], where OO is the object type indication and A the
+// audio object type. Dolby audio can be signaled this way as mp4a.a5 and mp4a.a6
+func (c *Codec) parseMPEG4Audio(parts []string) {
+	if len(parts) == 0 {
+		return
+	}
+
+	switch strings.ToLower(parts[0]) {
+	case "a5":
+		c.Family = FamilyAC3
+	case "a6":
+		c.Family = FamilyEAC3
+	case "40":
+		if len(parts) > 1 {
+			c.Profile = parts[1]
+			if name, found := mpeg4AudioProfiles[parts[1]]; found {
+				c.Profile = name
+			}
+		}
+	case "69", "6b":
+		c.Profile = "mp3"
+	}
+}
+
+func profileName(names map[int]string, profile int) string {
+	if name, found := names[profile]; found {
+		return name
+	}
+
+	return strconv.Itoa(profile)
+}
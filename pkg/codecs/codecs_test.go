@@ -0,0 +1,300 @@
+package codecs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		codec  string
+		expect Codec
+	}{
+		{
+			name:   "avc high profile",
+			codec:  "avc1.64001f",
+			expect: Codec{SampleEntry: "avc1", Family: FamilyAVC, Profile: "high", Level: 3.1},
+		},
+		{
+			name:   "avc main profile with in-band parameter sets",
+			codec:  "avc3.4d4028",
+			expect: Codec{SampleEntry: "avc3", Family: FamilyAVC, Profile: "main", Level: 4},
+		},
+		{
+			name:   "avc legacy decimal form",
+			codec:  "avc1.66.30",
+			expect: Codec{SampleEntry: "avc1", Family: FamilyAVC, Profile: "baseline", Level: 3},
+		},
+		{
+			name:   "hevc main10 profile",
+			codec:  "hvc1.2.4.L153.B0",
+			expect: Codec{SampleEntry: "hvc1", Family: FamilyHEVC, Profile: "main10", Tier: "main", Level: 5.1},
+		},
+		{
+			name:   "hevc main profile, high tier, signaled as hev1",
+			codec:  "hev1.1.6.H120.90",
+			expect: Codec{SampleEntry: "hev1", Family: FamilyHEVC, Profile: "main", Tier: "high", Level: 4},
+		},
+		{
+			name:   "hevc with profile space",
+			codec:  "hvc1.A4.10.L93.B0",
+			expect: Codec{SampleEntry: "hvc1", Family: FamilyHEVC, Profile: "rext", Tier: "main", Level: 3.1},
+		},
+		{
+			name:   "dolby vision profile 5",
+			codec:  "dvh1.05.06",
+			expect: Codec{SampleEntry: "dvh1", Family: FamilyDolbyVision, Profile: "5", Level: 6},
+		},
+		{
+			name:   "av1 main profile",
+			codec:  "av01.0.08M.08",
+			expect: Codec{SampleEntry: "av01", Family: FamilyAV1, Profile: "main", Tier: "main", Level: 4},
+		},
+		{
+			name:   "av1 main profile, high tier",
+			codec:  "av01.0.13H.10.0.110.09.16.09.0",
+			expect: Codec{SampleEntry: "av01", Family: FamilyAV1, Profile: "main", Tier: "high", Level: 5.1},
+		},
+		{
+			name:   "vp9 profile 2",
+			codec:  "vp09.02.41.10.01.09.16.09.00",
+			expect: Codec{SampleEntry: "vp09", Family: FamilyVP9, Profile: "2", Level: 4.1},
+		},
+		{
+			name:   "opus",
+			codec:  "Opus",
+			expect: Codec{SampleEntry: "opus", Family: FamilyOpus},
+		},
+		{
+			name:   "flac",
+			codec:  "fLaC",
+			expect: Codec{SampleEntry: "flac", Family: FamilyFLAC},
+		},
+		{
+			name:   "ac-4",
+			codec:  "ac-4.02.01.01",
+			expect: Codec{SampleEntry: "ac-4", Family: FamilyAC4},
+		},
+		{
+			name:   "aac lc",
+			codec:  "mp4a.40.2",
+			expect: Codec{SampleEntry: "mp4a", Family: FamilyMPEG4Audio, Profile: "lc"},
+		},
+		{
+			name:   "he-aac",
+			codec:  "mp4a.40.5",
+			expect: Codec{SampleEntry: "mp4a", Family: FamilyMPEG4Audio, Profile: "he"},
+		},
+		{
+			name:   "enhanced ac-3",
+			codec:  "ec-3",
+			expect: Codec{SampleEntry: "ec-3", Family: FamilyEAC3},
+		},
+		{
+			name:   "enhanced ac-3 signaled as mpeg-4 audio",
+			codec:  "mp4a.a6",
+			expect: Codec{SampleEntry: "mp4a", Family: FamilyEAC3},
+		},
+		{
+			name:   "ttml captions",
+			codec:  "stpp.ttml.im1t",
+			expect: Codec{SampleEntry: "stpp", Family: FamilyTTML},
+		},
+		{
+			name:   "unknown codec",
+			codec:  "xyz1.2",
+			expect: Codec{SampleEntry: "xyz1", Family: Family("xyz1")},
+		},
+		{
+			name:   "malformed avc",
+			codec:  "avc1.zz",
+			expect: Codec{SampleEntry: "avc1", Family: FamilyAVC},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expect, Parse(tt.codec)); diff != "" {
+				t.Errorf("Parse() wrong codec returned (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		codec  string
+		filter string
+		expect bool
+	}{
+		{
+			name:   "hvc matches hvc1",
+			codec:  "hvc1.2.4.L153.B0",
+			filter: "hvc",
+			expect: true,
+		},
+		{
+			name:   "hvc matches hev1",
+			codec:  "hev1.2.4.L153.B0",
+			filter: "hvc",
+			expect: true,
+		},
+		{
+			name:   "hevc matches hev1",
+			codec:  "hev1.1.6.L93.B0",
+			filter: "hevc",
+			expect: true,
+		},
+		{
+			name:   "avc doesn't match hevc",
+			codec:  "hvc1.2.4.L153.B0",
+			filter: "avc",
+			expect: false,
+		},
+		{
+			name:   "codec string prefix matches the same profile of any sample entry",
+			codec:  "hev1.2.4.L120.B0",
+			filter: "hvc1.2",
+			expect: true,
+		},
+		{
+			name:   "codec string prefix doesn't match other profiles",
+			codec:  "hvc1.1.6.L120.90",
+			filter: "hvc1.2",
+			expect: false,
+		},
+		{
+			name:   "av1 matches av01",
+			codec:  "av01.0.08M.08",
+			filter: "av1",
+			expect: true,
+		},
+		{
+			name:   "opus matches Opus",
+			codec:  "Opus",
+			filter: "opus",
+			expect: true,
+		},
+		{
+			name:   "ac-4 doesn't match ac-3",
+			codec:  "ac-4.02.01.01",
+			filter: "ac-3",
+			expect: false,
+		},
+		{
+			name:   "ec-3 doesn't match ac-3",
+			codec:  "ac-3",
+			filter: "ec-3",
+			expect: false,
+		},
+		{
+			name:   "mp4a doesn't match ec-3 signaled as mpeg-4 audio",
+			codec:  "mp4a.a6",
+			filter: "mp4a",
+			expect: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if g, e := Matches(tt.codec, tt.filter), tt.expect; g != e {
+				t.Errorf("Matches(%q, %q) = %v, expected %v", tt.codec, tt.filter, g, e)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expect        []string
+		expectAliased bool
+	}{
+		{
+			name:          "hevc expands to its family",
+			value:         "hevc",
+			expect:        []string{"hvc"},
+			expectAliased: true,
+		},
+		{
+			name:          "aliases are case insensitive",
+			value:         "DolbyVision",
+			expect:        []string{"dvh"},
+			expectAliased: true,
+		},
+		{
+			name:          "hdr10 expands to the main 10 profile of both hevc sample entries",
+			value:         "hdr10",
+			expect:        []string{"hev1.2", "hvc1.2"},
+			expectAliased: true,
+		},
+		{
+			name:   "family names are kept as they are",
+			value:  "ec-3",
+			expect: []string{"ec-3"},
+		},
+		{
+			name:   "codec strings are kept as they are",
+			value:  "hvc1.2",
+			expect: []string{"hvc1.2"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			expanded, aliased := Expand(tt.value)
+			if !cmp.Equal(expanded, tt.expect) || aliased != tt.expectAliased {
+				t.Errorf("Expand(%q) = %v, %v, expected %v, %v", tt.value, expanded, aliased, tt.expect,
+					tt.expectAliased)
+			}
+		})
+	}
+}
+
+func TestParseFamily(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expect      Family
+		expectFound bool
+	}{
+		{
+			name:        "family name",
+			value:       "avc",
+			expect:      FamilyAVC,
+			expectFound: true,
+		},
+		{
+			name:        "alias",
+			value:       "h265",
+			expect:      FamilyHEVC,
+			expectFound: true,
+		},
+		{
+			name:  "alias expanding to codec strings",
+			value: "hdr10",
+		},
+		{
+			name:  "unknown codec",
+			value: "vvc1",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			family, found := ParseFamily(tt.value)
+			if family != tt.expect || found != tt.expectFound {
+				t.Errorf("ParseFamily(%q) = %q, %v, expected %q, %v", tt.value, family, found, tt.expect,
+					tt.expectFound)
+			}
+		})
+	}
+}
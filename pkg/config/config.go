@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"net/http"
 	"os"
 	"time"
@@ -15,7 +16,18 @@ type Config struct {
 	LogLevel      string `envconfig:"LOG_LEVEL" default:"debug"`
 	OriginHost    string `envconfig:"ORIGIN_HOST"`
 	PropellerHost string `envconfig:"PROPELLER_HOST"`
+	PublicURL     string `envconfig:"PUBLIC_URL"`
 	Client        HTTPClient
+	LicenseURLs   LicenseURLTemplates `envconfig:"LICENSE_URL_TEMPLATES"`
+}
+
+// LicenseURLTemplates maps origin hosts to the template of the license URLs written in the
+// manifests they serve. It is read from JSON, e.g. {"streaming.cbs.com":"https://drm.cbs.com/{drm}"}
+type LicenseURLTemplates map[string]string
+
+// Decode reads the templates from their JSON representation
+func (t *LicenseURLTemplates) Decode(value string) error {
+	return json.Unmarshal([]byte(value), (*map[string]string)(t))
 }
 
 // HTTPClient will issue requests to the manifest
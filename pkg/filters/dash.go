@@ -1,18 +1,122 @@
 package filters
 
 import (
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/url"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cbsinteractive/bakery/pkg/config"
 	"github.com/cbsinteractive/bakery/pkg/parsers"
 	"github.com/zencoder/go-dash/mpd"
 )
 
-type execFilter func(filters *parsers.MediaFilters, manifest *mpd.MPD)
+type execFilter func(filters *parsers.MediaFilters, manifest *mpd.MPD) error
+
+// Schemes of the AudioChannelConfiguration descriptors, declaring a channel count, an index of
+// the channel configurations of ISO/IEC 23091-3, or a Dolby channel mask
+const (
+	channelConfigurationScheme            = "urn:mpeg:dash:23003:3:audio_channel_configuration:2011"
+	cicpChannelConfigurationScheme        = "urn:mpeg:mpegB:cicp:ChannelConfiguration"
+	dolbyChannelConfigurationScheme       = "tag:dolby.com,2014:dash:audio_channel_configuration:2011"
+	legacyDolbyChannelConfigurationScheme = "urn:dolby:dash:audio_channel_configuration:2011"
+)
+
+// ec3ExtensionTypeScheme is the scheme of the descriptor signaling Dolby Digital Plus extensions,
+// such as JOC for Dolby Atmos
+const ec3ExtensionTypeScheme = "tag:dolby.com,2018:dash:EC3_ExtensionType:2018"
+
+// cicpChannels is the channel count of the channel configurations of ISO/IEC 23091-3
+var cicpChannels = map[int]int{
+	1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 8, 9: 3, 10: 4, 11: 7,
+	12: 8, 13: 24, 14: 8, 15: 12, 16: 10, 17: 12, 18: 14, 19: 12, 20: 14,
+}
+
+// dolbyChannelLocations is the number of channels of each location of a Dolby channel mask,
+// starting from its most significant bit: L, C, R, Ls, Rs, Lc/Rc, Lrs/Rrs, Cs, Ts, Lsd/Rsd,
+// Lw/Rw, Vhl/Vhr, Vhc, Lts/Rts, LFE2 and LFE
+var dolbyChannelLocations = []int{1, 1, 1, 1, 1, 2, 2, 1, 1, 2, 2, 2, 1, 2, 1, 1}
+
+// cea608Scheme is the scheme of the Accessibility descriptors listing the CEA-608 closed
+// captions carried in a video stream
+const cea608Scheme = "urn:scte:dash:cc:cea-608:2015"
+
+// Descriptor schemes and values signaling the purpose of AdaptationSets
+const (
+	audioPurposeScheme           = "urn:tva:metadata:cs:AudioPurposeCS:2007"
+	audioPurposeVisuallyImpaired = "1"
+	roleScheme                   = "urn:mpeg:dash:role:2011"
+	roleMain                     = "main"
+	roleDescription              = "description"
+)
+
+// textRoles are the roles of the urn:mpeg:dash:role:2011 scheme only given to text AdaptationSets
+var textRoles = map[string]struct{}{
+	"caption":         {},
+	"subtitle":        {},
+	"forced-subtitle": {},
+	"easyreader":      {},
+}
+
+// transferCharacteristicsScheme is the scheme of the descriptors signaling the transfer
+// characteristics of video streams, using the code points of ITU-T H.273
+const (
+	transferCharacteristicsScheme = "urn:mpeg:mpegB:cicp:TransferCharacteristics"
+	transferCharacteristicsPQ     = "16"
+	transferCharacteristicsHLG    = "18"
+)
+
+// systemIDSchemePrefix prefixes the system ID of a DRM system in the scheme of its
+// ContentProtection descriptors
+const systemIDSchemePrefix = "urn:uuid:"
+
+// Namespaces of the elements declaring the license URL of a DRM system in its ContentProtection
+// descriptors
+const (
+	dashifNamespace    = "https://dashif.org/CPS"
+	microsoftNamespace = "urn:microsoft"
+)
+
+// licensedContentProtection is the ContentProtection descriptor of a DRM system declaring its
+// license URL in a dashif:laurl element, along with a ms:laurl one for PlayReady. It stands in
+// for the descriptors decoded by go-dash, which don't carry license URLs
+type licensedContentProtection struct {
+	XMLName             xml.Name          `xml:"ContentProtection"`
+	SchemeIDURI         *string           `xml:"schemeIdUri,attr"`
+	PlayreadyXMLNS      *string           `xml:"xmlns:mspr,attr,omitempty"`
+	Attrs               []*xml.Attr       `xml:",any,attr"`
+	LicenseURL          *dashifLicenseURL `xml:"dashif:laurl"`
+	PlayreadyLicenseURL *msLicenseURL     `xml:"ms:laurl,omitempty"`
+	PRO                 *string           `xml:"mspr:pro,omitempty"`
+	PSSH                *string           `xml:"cenc:pssh,omitempty"`
+}
+
+// ContentProtected implements mpd.ContentProtectioner
+func (licensedContentProtection) ContentProtected() {}
+
+type dashifLicenseURL struct {
+	XMLNS string `xml:"xmlns:dashif,attr"`
+	URL   string `xml:",chardata"`
+}
+
+type msLicenseURL struct {
+	XMLNS      string `xml:"xmlns:ms,attr"`
+	LicenseURL string `xml:"licenseUrl,attr"`
+}
+
+// isoDurationRegexp matches the xs:duration values of MPD attributes, e.g. PT1H2M3.5S
+var isoDurationRegexp = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// timelineSegment is a single segment of a SegmentTimeline, in the timescale of its template
+type timelineSegment struct {
+	start    uint64
+	duration uint64
+}
 
 // DASHFilter implements the Filter interface for DASH manifests
 type DASHFilter struct {
@@ -60,7 +164,9 @@ func (d *DASHFilter) FilterManifest(filters *parsers.MediaFilters) (string, erro
 	}
 
 	for _, filter := range d.getFilters(filters) {
-		filter(filters, manifest)
+		if err := filter(filters, manifest); err != nil {
+			return "", err
+		}
 	}
 
 	return manifest.WriteToString()
@@ -80,45 +186,802 @@ func (d *DASHFilter) getFilters(filters *parsers.MediaFilters) []execFilter {
 		filterList = append(filterList, d.filterAudioTypes)
 	}
 
+	if len(filters.AudioLanguages) > 0 {
+		filterList = append(filterList, d.filterAudioLanguages)
+	}
+
 	if filters.CaptionTypes != nil {
 		filterList = append(filterList, d.filterCaptionTypes)
 	}
 
-	if filters.DefinesBitrateFilter() {
+	if len(filters.CaptionLanguages) > 0 {
+		filterList = append(filterList, d.filterCaptionLanguages)
+	}
+
+	if filters.DefinesBitrateFilter() || filters.DefinesContentBitrateFilter() {
 		filterList = append(filterList, d.filterBandwidth)
 	}
 
+	if filters.DefinesResolutionFilter() {
+		filterList = append(filterList, d.filterResolution)
+	}
+
+	if filters.DefinesFrameRateFilter() {
+		filterList = append(filterList, d.filterFrameRate)
+	}
+
+	if filters.DefinesCodecCapsFilter() {
+		filterList = append(filterList, d.filterCodecCaps)
+	}
+
+	if filters.DefinesDynamicRangeFilter() {
+		filterList = append(filterList, d.filterDynamicRange)
+	}
+
+	if filters.DefinesAudioChannelsFilter() {
+		filterList = append(filterList, d.filterAudioChannels)
+	}
+
+	if filters.AudioDescription != "" {
+		filterList = append(filterList, d.filterAudioDescription)
+	}
+
+	if len(filters.Roles) > 0 {
+		filterList = append(filterList, d.filterRoles)
+	}
+
+	if len(filters.ClosedCaptions) > 0 {
+		filterList = append(filterList, d.filterClosedCaptions)
+	}
+
+	if len(filters.DRMSystems) > 0 {
+		filterList = append(filterList, d.filterDRMSystems)
+	}
+
+	if filters.Clip != nil {
+		filterList = append(filterList, d.clipPresentation)
+	}
+
+	if filters.DVRWindow > 0 {
+		filterList = append(filterList, d.filterDVRWindow)
+	}
+
+	if _, found := licenseURLTemplate(d.config, d.manifestURL); found {
+		filterList = append(filterList, d.rewriteLicenseURLs)
+	}
+
 	return filterList
 }
 
-func (d *DASHFilter) filterVideoTypes(filters *parsers.MediaFilters, manifest *mpd.MPD) {
+func (d *DASHFilter) filterVideoTypes(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
 	supportedVideoTypes := map[string]struct{}{}
 	for _, videoType := range filters.Videos {
 		supportedVideoTypes[string(videoType)] = struct{}{}
 	}
 
-	filterContentType(videoContentType, supportedVideoTypes, manifest)
+	filterContentType(videoContentType, supportedVideoTypes, filters.VideoMode, manifest)
+
+	return nil
 }
 
-func (d *DASHFilter) filterAudioTypes(filters *parsers.MediaFilters, manifest *mpd.MPD) {
+func (d *DASHFilter) filterAudioTypes(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
 	supportedAudioTypes := map[string]struct{}{}
 	for _, audioType := range filters.Audios {
 		supportedAudioTypes[string(audioType)] = struct{}{}
 	}
 
-	filterContentType(audioContentType, supportedAudioTypes, manifest)
+	filterContentType(audioContentType, supportedAudioTypes, filters.AudioMode, manifest)
+
+	return nil
 }
 
-func (d *DASHFilter) filterCaptionTypes(filters *parsers.MediaFilters, manifest *mpd.MPD) {
+func (d *DASHFilter) filterCaptionTypes(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
 	supportedCaptionTypes := map[string]struct{}{}
 	for _, captionType := range filters.CaptionTypes {
 		supportedCaptionTypes[string(captionType)] = struct{}{}
 	}
 
-	filterContentType(captionContentType, supportedCaptionTypes, manifest)
+	filterContentType(captionContentType, supportedCaptionTypes, filters.CaptionTypeMode, manifest)
+
+	return nil
+}
+
+func (d *DASHFilter) filterAudioLanguages(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	filteredLanguages := map[string]struct{}{}
+	for _, audioLanguage := range filters.AudioLanguages {
+		filteredLanguages[strings.ToLower(string(audioLanguage))] = struct{}{}
+	}
+
+	if removedAll := filterLanguages(audioContentType, filteredLanguages, manifest); removedAll {
+		return fmt.Errorf("audio language filter %v removes every audio track", filters.AudioLanguages)
+	}
+
+	return nil
+}
+
+func (d *DASHFilter) filterCaptionLanguages(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	filteredLanguages := map[string]struct{}{}
+	for _, captionLanguage := range filters.CaptionLanguages {
+		filteredLanguages[strings.ToLower(string(captionLanguage))] = struct{}{}
+	}
+
+	filterLanguages(captionContentType, filteredLanguages, manifest)
+
+	return nil
+}
+
+func (d *DASHFilter) filterAudioDescription(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	only := filters.AudioDescription == parsers.AudioDescriptionOnly
+	removedAll := filterAdaptationSets(audioContentType, manifest, func(as *mpd.AdaptationSet) bool {
+		return describesVideo(as) == only
+	})
+
+	if removedAll {
+		return fmt.Errorf("audio description filter %q removes every audio track", filters.AudioDescription)
+	}
+
+	return nil
+}
+
+// describesVideo returns true if an AdaptationSet carries audio description for the visually
+// impaired, as signaled by its Accessibility or Role descriptors
+func describesVideo(as *mpd.AdaptationSet) bool {
+	for _, a := range as.AccessibilityElems {
+		if a.SchemeIdUri != nil && a.Value != nil && isAudioDescription(*a.SchemeIdUri, *a.Value) {
+			return true
+		}
+	}
+
+	for _, r := range as.Roles {
+		if r.SchemeIDURI != nil && r.Value != nil && isAudioDescription(*r.SchemeIDURI, *r.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAudioDescription returns true if a descriptor signals audio description, either as the
+// visually impaired audio purpose of TV-Anytime or as the description role of MPEG-DASH
+func isAudioDescription(scheme, value string) bool {
+	return (scheme == audioPurposeScheme && value == audioPurposeVisuallyImpaired) ||
+		(scheme == roleScheme && value == roleDescription)
+}
+
+func (d *DASHFilter) filterRoles(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	filteredRoles := map[string]struct{}{}
+	for _, role := range filters.Roles {
+		filteredRoles[string(role)] = struct{}{}
+	}
+
+	include := filters.RoleMode == parsers.FilterModeInclude
+	keep := func(as *mpd.AdaptationSet) bool {
+		return hasRole(as, filteredRoles) == include
+	}
+
+	// in include mode, the roles listed only apply to the content types they are given to, so
+	// that keeping main audio doesn't remove subtitles
+	filtersText, filtersMedia := !include, !include
+	for role := range filteredRoles {
+		_, text := textRoles[role]
+		filtersText = filtersText || text
+		filtersMedia = filtersMedia || !text
+	}
+
+	if filtersText {
+		filterAdaptationSets(captionContentType, manifest, keep)
+	}
+
+	if !filtersMedia {
+		return nil
+	}
+
+	filterAdaptationSets(videoContentType, manifest, keep)
+	if removedAll := filterAdaptationSets(audioContentType, manifest, keep); removedAll {
+		return fmt.Errorf("role filter %v removes every audio track", filters.Roles)
+	}
+
+	return nil
+}
+
+// hasRole returns true if any of the roles of an AdaptationSet is in roles. AdaptationSets
+// without a role of the urn:mpeg:dash:role:2011 scheme are main ones
+func hasRole(as *mpd.AdaptationSet, roles map[string]struct{}) bool {
+	declared := false
+	for _, r := range as.Roles {
+		if r.SchemeIDURI == nil || *r.SchemeIDURI != roleScheme || r.Value == nil {
+			continue
+		}
+
+		declared = true
+		if _, found := roles[strings.ToLower(*r.Value)]; found {
+			return true
+		}
+	}
+
+	if !declared {
+		_, found := roles[roleMain]
+		return found
+	}
+
+	return false
+}
+
+func (d *DASHFilter) filterClosedCaptions(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	for _, period := range manifest.Periods {
+		for _, as := range period.AdaptationSets {
+			var accessibility []*mpd.Accessibility
+			for _, a := range as.AccessibilityElems {
+				if a.SchemeIdUri == nil || *a.SchemeIdUri != cea608Scheme || a.Value == nil {
+					accessibility = append(accessibility, a)
+					continue
+				}
+
+				if channels := filterCEA608Channels(filters, *a.Value); channels != "" {
+					a.Value = strptr(channels)
+					accessibility = append(accessibility, a)
+				}
+			}
+			as.AccessibilityElems = accessibility
+		}
+	}
+
+	return nil
+}
+
+// filterCEA608Channels removes the channels rejected by the closed captions filter from the
+// value of a CEA-608 Accessibility descriptor, e.g. CC1=eng;CC3=spa, in which channel numbers
+// are optional. It returns an empty value when every channel is removed
+func filterCEA608Channels(filters *parsers.MediaFilters, value string) string {
+	var channels []string
+	for _, channel := range strings.Split(value, ";") {
+		var instreamID, language string
+		if i := strings.Index(channel, "="); i >= 0 {
+			instreamID, language = channel[:i], channel[i+1:]
+		} else {
+			language = channel
+		}
+
+		if keepsClosedCaptions(filters, instreamID, language) {
+			channels = append(channels, channel)
+		}
+	}
+
+	return strings.Join(channels, ";")
 }
 
-func filterContentType(filter ContentType, supportedContentTypes map[string]struct{}, manifest *mpd.MPD) {
+func (d *DASHFilter) filterDRMSystems(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	for _, period := range manifest.Periods {
+		for _, as := range period.AdaptationSets {
+			as.ContentProtection = filterContentProtection(filters, as.ContentProtection)
+			as.CommonAttributesAndElements.ContentProtection = filterContentProtection(filters, as.CommonAttributesAndElements.ContentProtection)
+			for _, r := range as.Representations {
+				r.ContentProtection = filterContentProtection(filters, r.ContentProtection)
+			}
+		}
+	}
+
+	return nil
+}
+
+// filterContentProtection removes the ContentProtection descriptors of the DRM systems not listed
+// by the drm filter. Descriptors that don't identify a DRM system by its system ID, such as the
+// urn:mpeg:dash:mp4protection:2011 one, are kept
+func filterContentProtection(filters *parsers.MediaFilters, descriptors []mpd.ContentProtectioner) []mpd.ContentProtectioner {
+	var filtered []mpd.ContentProtectioner
+	for _, cp := range descriptors {
+		scheme := strings.ToLower(contentProtectionScheme(cp))
+		if !strings.HasPrefix(scheme, systemIDSchemePrefix) {
+			filtered = append(filtered, cp)
+			continue
+		}
+
+		systemID := strings.TrimPrefix(scheme, systemIDSchemePrefix)
+		if keepsDRMSystem(filters, func(system drmSystem) bool { return system.systemID == systemID }) {
+			filtered = append(filtered, cp)
+		}
+	}
+
+	return filtered
+}
+
+// contentProtectionScheme returns the schemeIdUri of a ContentProtection descriptor, whose type
+// depends on the DRM system it was decoded for
+func contentProtectionScheme(cp mpd.ContentProtectioner) string {
+	var scheme *string
+	switch p := cp.(type) {
+	case *mpd.ContentProtection:
+		scheme = p.SchemeIDURI
+	case *mpd.CENCContentProtection:
+		scheme = p.SchemeIDURI
+	case *mpd.PlayreadyContentProtection:
+		scheme = p.SchemeIDURI
+	case *mpd.WidevineContentProtection:
+		scheme = p.SchemeIDURI
+	case *licensedContentProtection:
+		scheme = p.SchemeIDURI
+	}
+
+	if scheme == nil {
+		return ""
+	}
+
+	return *scheme
+}
+
+// rewriteLicenseURLs declares the license URL configured for the origin of the manifest in the
+// ContentProtection descriptors of every DRM system, replacing the license URLs they declared
+func (d *DASHFilter) rewriteLicenseURLs(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	template, _ := licenseURLTemplate(d.config, d.manifestURL)
+	for _, period := range manifest.Periods {
+		for _, as := range period.AdaptationSets {
+			withLicenseURLs(template, as.ContentProtection)
+			withLicenseURLs(template, as.CommonAttributesAndElements.ContentProtection)
+			for _, r := range as.Representations {
+				withLicenseURLs(template, r.ContentProtection)
+			}
+		}
+	}
+
+	return nil
+}
+
+// withLicenseURLs replaces the descriptors identifying a DRM system by its system ID with ones
+// declaring the license URL expanded from template
+func withLicenseURLs(template string, descriptors []mpd.ContentProtectioner) {
+	for i, cp := range descriptors {
+		scheme := contentProtectionScheme(cp)
+		if !strings.HasPrefix(strings.ToLower(scheme), systemIDSchemePrefix) {
+			continue
+		}
+
+		systemID := strings.TrimPrefix(strings.ToLower(scheme), systemIDSchemePrefix)
+		system := drmSystemName(func(system drmSystem) bool { return system.systemID == systemID }, systemID)
+		licensed := newLicensedContentProtection(cp)
+		licensed.SchemeIDURI = strptr(scheme)
+		licensed.LicenseURL = &dashifLicenseURL{XMLNS: dashifNamespace, URL: licenseURL(template, system, "")}
+		licensed.PlayreadyLicenseURL = nil
+		if system == string(parsers.DRMSystemPlayReady) {
+			licensed.PlayreadyLicenseURL = &msLicenseURL{XMLNS: microsoftNamespace, LicenseURL: licensed.LicenseURL.URL}
+		}
+
+		descriptors[i] = licensed
+	}
+}
+
+// newLicensedContentProtection copies the attributes and PSSH data of a ContentProtection
+// descriptor decoded by go-dash
+func newLicensedContentProtection(cp mpd.ContentProtectioner) *licensedContentProtection {
+	switch p := cp.(type) {
+	case *licensedContentProtection:
+		return p
+	case *mpd.PlayreadyContentProtection:
+		return &licensedContentProtection{
+			Attrs:          p.Attrs,
+			PlayreadyXMLNS: p.PlayreadyXMLNS,
+			PRO:            p.PRO,
+			PSSH:           p.PSSH,
+		}
+	case *mpd.WidevineContentProtection:
+		return &licensedContentProtection{Attrs: p.Attrs, PSSH: p.PSSH}
+	case *mpd.ContentProtection:
+		return &licensedContentProtection{Attrs: p.Attrs}
+	}
+
+	return &licensedContentProtection{}
+}
+
+// clipPresentation cuts the clip out of a static single period presentation. The segments
+// covering the clip are kept and the presentation starts at the start of the clip through the
+// presentationTimeOffset of SegmentTimeline based templates, while the templates addressing
+// segments by their duration are clipped at segment boundaries
+func (d *DASHFilter) clipPresentation(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	if manifest.Type != nil && *manifest.Type == "dynamic" {
+		return errors.New("time clipping requires a static manifest")
+	}
+
+	if len(manifest.Periods) != 1 {
+		return errors.New("time clipping requires a single period manifest")
+	}
+
+	clip := *filters.Clip
+	if manifest.MediaPresentationDuration != nil {
+		duration, err := parseISODuration(*manifest.MediaPresentationDuration)
+		if err == nil && duration < clip.End {
+			clip.End = duration
+		}
+	}
+
+	if clip.End <= clip.Start {
+		return fmt.Errorf("time clip from %v to %v is outside of the presentation", filters.Clip.Start, filters.Clip.End)
+	}
+
+	period := manifest.Periods[0]
+	templates := segmentTemplates(period)
+	if len(templates) == 0 {
+		return errors.New("time clipping requires SegmentTemplate addressing")
+	}
+
+	// the presentation starts where the earliest template starts, which is before the start of
+	// the clip when segments addressed by their duration are clipped at a segment boundary
+	start := clip.Start
+	for _, template := range templates {
+		templateStart, err := clipSegmentTemplate(template, clip)
+		if err != nil {
+			return err
+		}
+
+		if templateStart < start {
+			start = templateStart
+		}
+	}
+
+	manifest.MediaPresentationDuration = strptr(formatISODuration(clip.End - start))
+	if period.Duration != 0 {
+		period.Duration = mpd.Duration(clip.End - start)
+	}
+
+	return nil
+}
+
+// segmentTemplates returns every SegmentTemplate of a period, its AdaptationSets and their
+// representations
+func segmentTemplates(period *mpd.Period) []*mpd.SegmentTemplate {
+	var templates []*mpd.SegmentTemplate
+	if period.SegmentTemplate != nil {
+		templates = append(templates, period.SegmentTemplate)
+	}
+
+	for _, as := range period.AdaptationSets {
+		if as.SegmentTemplate != nil {
+			templates = append(templates, as.SegmentTemplate)
+		}
+
+		for _, r := range as.Representations {
+			if r.SegmentTemplate != nil {
+				templates = append(templates, r.SegmentTemplate)
+			}
+		}
+	}
+
+	return templates
+}
+
+// clipSegmentTemplate keeps the segments of a template covering any part of clip, moving its
+// startNumber past the removed segments. It returns the time of the original presentation the
+// template starts at once clipped
+func clipSegmentTemplate(template *mpd.SegmentTemplate, clip parsers.TimeRange) (time.Duration, error) {
+	timescale := templateTimescale(template)
+	presentationTimeOffset := templatePresentationTimeOffset(template)
+	startNumber := templateStartNumber(template)
+
+	start := presentationTimeOffset + mediaTime(clip.Start, timescale)
+	if template.SegmentTimeline == nil {
+		if template.Duration == nil || *template.Duration <= 0 {
+			return 0, errors.New("time clipping requires SegmentTemplate addressing")
+		}
+
+		removed := mediaTime(clip.Start, timescale) / uint64(*template.Duration)
+		template.StartNumber = &startNumber
+		*template.StartNumber += int64(removed)
+		template.PresentationTimeOffset = &presentationTimeOffset
+		*template.PresentationTimeOffset += removed * uint64(*template.Duration)
+
+		return mediaDuration(removed*uint64(*template.Duration), timescale), nil
+	}
+
+	segments, openEnded := expandTimeline(template.SegmentTimeline)
+	if openEnded {
+		return 0, errors.New("time clipping requires SegmentTimeline entries with a repeat count")
+	}
+
+	end := presentationTimeOffset + mediaTime(clip.End, timescale)
+	var kept []timelineSegment
+	removed := 0
+	for _, s := range segments {
+		switch {
+		case s.start+s.duration <= start:
+			removed++
+		case s.start < end:
+			kept = append(kept, s)
+		}
+	}
+
+	if len(kept) == 0 {
+		return 0, fmt.Errorf("time clip from %v to %v is outside of the presentation", clip.Start, clip.End)
+	}
+
+	template.SegmentTimeline.Segments = compressTimeline(kept)
+	template.PresentationTimeOffset = &start
+	if removed > 0 {
+		template.StartNumber = &startNumber
+		*template.StartNumber += int64(removed)
+	}
+
+	return clip.Start, nil
+}
+
+func (d *DASHFilter) filterDVRWindow(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	if manifest.Type == nil || *manifest.Type != "dynamic" {
+		return nil
+	}
+
+	if manifest.TimeShiftBufferDepth != nil {
+		depth, err := parseISODuration(*manifest.TimeShiftBufferDepth)
+		if err == nil && depth <= filters.DVRWindow {
+			return nil
+		}
+	}
+	manifest.TimeShiftBufferDepth = strptr(formatISODuration(filters.DVRWindow))
+
+	// the live edge is the end of the most recent segment of any SegmentTimeline. Timelines whose
+	// last entry repeats until the end of the period have no known end, so they are left as they
+	// are along with their period
+	var timelines []*periodTimeline
+	var liveEdge time.Duration
+	openPeriods := map[*mpd.Period]struct{}{}
+	for _, period := range manifest.Periods {
+		for _, template := range segmentTemplates(period) {
+			if template.SegmentTimeline == nil {
+				continue
+			}
+
+			segments, openEnded := expandTimeline(template.SegmentTimeline)
+			if openEnded {
+				openPeriods[period] = struct{}{}
+				continue
+			}
+
+			if len(segments) == 0 {
+				continue
+			}
+
+			timeline := &periodTimeline{period: period, template: template, segments: segments}
+			last := segments[len(segments)-1]
+			if end := timeline.presentationTime(last.start + last.duration); end > liveEdge {
+				liveEdge = end
+			}
+			timelines = append(timelines, timeline)
+		}
+	}
+
+	windowStart := liveEdge - filters.DVRWindow
+	emptiedPeriods := map[*mpd.Period]struct{}{}
+	for _, timeline := range timelines {
+		var kept []timelineSegment
+		for _, s := range timeline.segments {
+			if timeline.presentationTime(s.start+s.duration) > windowStart {
+				kept = append(kept, s)
+			}
+		}
+
+		removed := len(timeline.segments) - len(kept)
+		switch {
+		case removed == 0:
+			continue
+		case len(kept) == 0:
+			emptiedPeriods[timeline.period] = struct{}{}
+			continue
+		}
+
+		timeline.template.SegmentTimeline.Segments = compressTimeline(kept)
+		startNumber := templateStartNumber(timeline.template) + int64(removed)
+		timeline.template.StartNumber = &startNumber
+	}
+
+	// periods that ended before the window starts are removed, except for the last one
+	var periods []*mpd.Period
+	for i, period := range manifest.Periods {
+		_, emptied := emptiedPeriods[period]
+		if _, open := openPeriods[period]; emptied && !open && i < len(manifest.Periods)-1 {
+			continue
+		}
+		periods = append(periods, period)
+	}
+	manifest.Periods = periods
+
+	return nil
+}
+
+// periodTimeline is the SegmentTimeline of a template along with the period it belongs to
+type periodTimeline struct {
+	period   *mpd.Period
+	template *mpd.SegmentTemplate
+	segments []timelineSegment
+}
+
+// presentationTime converts a time in the timescale of the template to the presentation time
+// line, which starts with the first period
+func (t *periodTimeline) presentationTime(mediaTime uint64) time.Duration {
+	var start time.Duration
+	if t.period.Start != nil {
+		start = time.Duration(*t.period.Start)
+	}
+
+	timescale := int64(templateTimescale(t.template))
+	offset := int64(mediaTime) - int64(templatePresentationTimeOffset(t.template))
+	return start + time.Duration(offset/timescale)*time.Second +
+		time.Duration(offset%timescale)*time.Second/time.Duration(timescale)
+}
+
+func templateTimescale(template *mpd.SegmentTemplate) uint64 {
+	if template.Timescale != nil && *template.Timescale > 0 {
+		return uint64(*template.Timescale)
+	}
+
+	return 1
+}
+
+func templatePresentationTimeOffset(template *mpd.SegmentTemplate) uint64 {
+	if template.PresentationTimeOffset != nil {
+		return *template.PresentationTimeOffset
+	}
+
+	return 0
+}
+
+func templateStartNumber(template *mpd.SegmentTemplate) int64 {
+	if template.StartNumber != nil {
+		return *template.StartNumber
+	}
+
+	return 1
+}
+
+// expandTimeline lists every segment of a SegmentTimeline, repeating its S elements. Entries
+// with a negative repeat count are repeated until the start time of the next entry, and the
+// timeline is reported as open ended, without listing the segments of its last entry, when that
+// entry is repeated until the end of the period
+func expandTimeline(timeline *mpd.SegmentTimeline) ([]timelineSegment, bool) {
+	var segments []timelineSegment
+	var t uint64
+	for i, s := range timeline.Segments {
+		if s.StartTime != nil {
+			t = *s.StartTime
+		}
+
+		repeat := 0
+		if s.RepeatCount != nil {
+			repeat = *s.RepeatCount
+		}
+
+		if repeat < 0 {
+			if i == len(timeline.Segments)-1 || timeline.Segments[i+1].StartTime == nil || s.Duration == 0 {
+				return segments, true
+			}
+
+			next := *timeline.Segments[i+1].StartTime
+			repeat = -1
+			if next > t {
+				repeat = int((next-t+s.Duration-1)/s.Duration) - 1
+			}
+		}
+
+		for r := 0; r <= repeat; r++ {
+			segments = append(segments, timelineSegment{start: t, duration: s.Duration})
+			t += s.Duration
+		}
+	}
+
+	return segments, false
+}
+
+// compressTimeline builds the S elements of a SegmentTimeline listing segments, repeating the
+// ones of contiguous segments of the same duration
+func compressTimeline(segments []timelineSegment) []*mpd.SegmentTimelineSegment {
+	var timeline []*mpd.SegmentTimelineSegment
+	var next uint64
+	for i, s := range segments {
+		if last := len(timeline) - 1; i > 0 && s.start == next && timeline[last].Duration == s.duration {
+			if timeline[last].RepeatCount == nil {
+				timeline[last].RepeatCount = new(int)
+			}
+			*timeline[last].RepeatCount++
+		} else {
+			entry := &mpd.SegmentTimelineSegment{Duration: s.duration}
+			if i == 0 || s.start != next {
+				start := s.start
+				entry.StartTime = &start
+			}
+			timeline = append(timeline, entry)
+		}
+
+		next = s.start + s.duration
+	}
+
+	return timeline
+}
+
+// mediaDuration converts a time in the timescale of a SegmentTemplate to a duration, the
+// reverse of mediaTime
+func mediaDuration(t, timescale uint64) time.Duration {
+	return time.Duration(t/timescale)*time.Second + time.Duration(t%timescale)*time.Second/time.Duration(timescale)
+}
+
+// mediaTime converts a duration to the timescale of a SegmentTemplate. Whole seconds are
+// converted apart from their fraction, so that large timescales don't overflow
+func mediaTime(d time.Duration, timescale uint64) uint64 {
+	return uint64(d/time.Second)*timescale + uint64(d%time.Second)*timescale/uint64(time.Second)
+}
+
+// parseISODuration reads the xs:duration values of MPD attributes, which are given in days at most
+func parseISODuration(value string) (time.Duration, error) {
+	matches := isoDurationRegexp.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute} {
+		if matches[i+1] != "" {
+			n, _ := strconv.Atoi(matches[i+1])
+			d += time.Duration(n) * unit
+		}
+	}
+
+	if matches[4] != "" {
+		seconds, _ := strconv.ParseFloat(matches[4], 64)
+		d += time.Duration(seconds * float64(time.Second))
+	}
+
+	return d, nil
+}
+
+// formatISODuration writes a duration as a xs:duration value in seconds, e.g. PT90.5S
+func formatISODuration(d time.Duration) string {
+	return "PT" + strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S"
+}
+
+// filterLanguages removes the AdaptationSets of content type filter whose language is one of
+// filteredLanguages. It returns true when a period was left without AdaptationSets of that type
+func filterLanguages(filter ContentType, filteredLanguages map[string]struct{}, manifest *mpd.MPD) bool {
+	return filterAdaptationSets(filter, manifest, func(as *mpd.AdaptationSet) bool {
+		if as.Lang == nil {
+			return true
+		}
+
+		_, filtered := filteredLanguages[strings.ToLower(*as.Lang)]
+		return !filtered
+	})
+}
+
+// filterAdaptationSets removes the AdaptationSets of content type filter rejected by keep. It
+// returns true when a period was left without AdaptationSets of that type
+func filterAdaptationSets(filter ContentType, manifest *mpd.MPD, keep func(*mpd.AdaptationSet) bool) bool {
+	removedAll := false
+	for _, period := range manifest.Periods {
+		var filteredAdaptationSets []*mpd.AdaptationSet
+		removed, remaining := 0, 0
+		for _, as := range period.AdaptationSets {
+			if as.ContentType != nil && *as.ContentType == string(filter) {
+				if !keep(as) {
+					removed++
+					continue
+				}
+				remaining++
+			}
+
+			filteredAdaptationSets = append(filteredAdaptationSets, as)
+		}
+
+		if removed > 0 && remaining == 0 {
+			removedAll = true
+		}
+
+		for i, as := range filteredAdaptationSets {
+			as.ID = strptr(strconv.Itoa(i))
+		}
+		period.AdaptationSets = filteredAdaptationSets
+	}
+
+	return removedAll
+}
+
+// filterContentType removes the representations of filter content type whose codec is in
+// supportedContentTypes, or whose codec is not in it when mode is include
+func filterContentType(filter ContentType, supportedContentTypes map[string]struct{}, mode parsers.FilterMode, manifest *mpd.MPD) {
+	include := mode == parsers.FilterModeInclude
+
 	for _, period := range manifest.Periods {
 		var filteredAdaptationSets []*mpd.AdaptationSet
 		for _, as := range period.AdaptationSets {
@@ -130,7 +993,7 @@ func filterContentType(filter ContentType, supportedContentTypes map[string]stru
 						continue
 					}
 
-					if matchCodec(*r.Codecs, filter, supportedContentTypes) {
+					if matchCodec(*r.Codecs, supportedContentTypes) != include {
 						continue
 					}
 
@@ -151,7 +1014,7 @@ func filterContentType(filter ContentType, supportedContentTypes map[string]stru
 	}
 }
 
-func (d *DASHFilter) filterAdaptationSetType(filters *parsers.MediaFilters, manifest *mpd.MPD) {
+func (d *DASHFilter) filterAdaptationSetType(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
 	filteredAdaptationSetTypes := map[parsers.StreamType]struct{}{}
 	for _, streamType := range filters.FilterStreamTypes {
 		filteredAdaptationSetTypes[streamType] = struct{}{}
@@ -187,16 +1050,11 @@ func (d *DASHFilter) filterAdaptationSetType(filters *parsers.MediaFilters, mani
 	}
 
 	manifest.Periods = filteredPeriods
-}
 
-func matchCodec(codec string, ct ContentType, supportedCodecs map[string]struct{}) bool {
-	//the key in supportedCodecs for captionContentType is equivalent to codec
-	//advertised in manifest. we can avoid iterating through each key
-	if ct == captionContentType {
-		_, found := supportedCodecs[codec]
-		return found
-	}
+	return nil
+}
 
+func matchCodec(codec string, supportedCodecs map[string]struct{}) bool {
 	for key := range supportedCodecs {
 		if ValidCodecs(codec, CodecFilterID(key)) {
 			return true
@@ -206,21 +1064,306 @@ func matchCodec(codec string, ct ContentType, supportedCodecs map[string]struct{
 	return false
 }
 
-func (d *DASHFilter) filterBandwidth(filters *parsers.MediaFilters, manifest *mpd.MPD) {
+func (d *DASHFilter) filterBandwidth(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
 	for _, period := range manifest.Periods {
 		var filteredAdaptationSets []*mpd.AdaptationSet
 
 		for _, as := range period.AdaptationSets {
 			var filteredRepresentations []*mpd.Representation
 
+			contentBitrate := contentBitrateRange(filters, as)
+
 			for _, r := range as.Representations {
 				if r.Bandwidth == nil {
 					continue
 				}
-				if *r.Bandwidth <= int64(filters.MaxBitrate) && *r.Bandwidth >= int64(filters.MinBitrate) {
+				if filters.DefinesBitrateFilter() &&
+					(*r.Bandwidth > int64(filters.MaxBitrate) || *r.Bandwidth < int64(filters.MinBitrate)) {
+					continue
+				}
+				if contentBitrate != nil && !contentBitrate.Contains(int(*r.Bandwidth)) {
+					continue
+				}
+
+				filteredRepresentations = append(filteredRepresentations, r)
+			}
+			as.Representations = filteredRepresentations
+			if len(as.Representations) != 0 {
+				filteredAdaptationSets = append(filteredAdaptationSets, as)
+			}
+		}
+
+		period.AdaptationSets = filteredAdaptationSets
+
+		// Recalculate AdaptationSet id numbers
+		for index, as := range period.AdaptationSets {
+			as.ID = strptr(strconv.Itoa(index))
+		}
+	}
+
+	return nil
+}
+
+// contentBitrateRange returns the bitrate range given for the content type of an AdaptationSet, if any
+func contentBitrateRange(filters *parsers.MediaFilters, as *mpd.AdaptationSet) *parsers.BitrateRange {
+	if as.ContentType == nil {
+		return nil
+	}
+
+	switch ContentType(*as.ContentType) {
+	case videoContentType:
+		return filters.VideoBitrate
+	case audioContentType:
+		return filters.AudioBitrate
+	}
+
+	return nil
+}
+
+// filterResolution removes the video representations whose height is outside the resolution
+// filter range. Representations without a height fall back to the maxHeight of their
+// AdaptationSet, and are kept when neither is set
+func (d *DASHFilter) filterResolution(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	return filterRepresentations(manifest, videoContentType, func(as *mpd.AdaptationSet, r *mpd.Representation) (bool, error) {
+		height, found := representationHeight(as, r)
+		return !found || filters.ValidHeight(height), nil
+	})
+}
+
+func representationHeight(as *mpd.AdaptationSet, r *mpd.Representation) (int, bool) {
+	if r.Height != nil {
+		return int(*r.Height), true
+	}
+
+	if as.MaxHeight != nil {
+		height, err := strconv.Atoi(*as.MaxHeight)
+		return height, err == nil
+	}
+
+	return 0, false
+}
+
+// filterFrameRate removes the video representations with a frame rate above the frame rate
+// filter. Representations without a frameRate fall back to the frameRate of their
+// AdaptationSet, and are kept when neither is set or the frame rate can't be parsed
+func (d *DASHFilter) filterFrameRate(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	return filterRepresentations(manifest, videoContentType, func(as *mpd.AdaptationSet, r *mpd.Representation) (bool, error) {
+		frameRate := r.FrameRate
+		if frameRate == nil {
+			frameRate = as.FrameRate
+		}
+
+		if frameRate == nil {
+			return true, nil
+		}
+
+		rate, err := parseFrameRate(*frameRate)
+		if err != nil {
+			return true, nil
+		}
+
+		return rate <= filters.MaxFrameRate, nil
+	})
+}
+
+// filterCodecCaps removes the video representations whose codec profile or level is not
+// allowed by the video profile and level caps
+func (d *DASHFilter) filterCodecCaps(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	return filterRepresentations(manifest, videoContentType, func(as *mpd.AdaptationSet, r *mpd.Representation) (bool, error) {
+		codec := r.Codecs
+		if codec == nil {
+			codec = as.Codecs
+		}
+
+		return codec == nil || !exceedsCodecCaps(filters, *codec), nil
+	})
+}
+
+func (d *DASHFilter) filterDynamicRange(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	return filterRepresentations(manifest, videoContentType, func(as *mpd.AdaptationSet, r *mpd.Representation) (bool, error) {
+		var codec string
+		if r.Codecs != nil {
+			codec = *r.Codecs
+		} else if as.Codecs != nil {
+			codec = *as.Codecs
+		}
+
+		return allowsDynamicRanges(filters, dynamicRanges(codec, transferCharacteristicsRange(as, r))), nil
+	})
+}
+
+// transferCharacteristicsRange returns the dynamic range signaled by the transfer characteristics
+// descriptors of a representation, falling back to the ones of its AdaptationSet, or an empty
+// range when none is signaled
+func transferCharacteristicsRange(as *mpd.AdaptationSet, r *mpd.Representation) parsers.DynamicRange {
+	descriptors := [][]mpd.DescriptorType{
+		r.EssentialProperty, r.SupplementalProperty,
+		as.EssentialProperty, as.SupplementalProperty,
+	}
+
+	for _, properties := range descriptors {
+		for _, p := range properties {
+			if p.SchemeIDURI == nil || *p.SchemeIDURI != transferCharacteristicsScheme || p.Value == nil {
+				continue
+			}
+
+			switch *p.Value {
+			case transferCharacteristicsPQ:
+				return parsers.DynamicRangePQ
+			case transferCharacteristicsHLG:
+				return parsers.DynamicRangeHLG
+			default:
+				return parsers.DynamicRangeSDR
+			}
+		}
+	}
+
+	return ""
+}
+
+func (d *DASHFilter) filterAudioChannels(filters *parsers.MediaFilters, manifest *mpd.MPD) error {
+	audioPeriods := map[*mpd.Period]struct{}{}
+	for _, period := range manifest.Periods {
+		for _, as := range period.AdaptationSets {
+			if as.ContentType != nil && *as.ContentType == string(audioContentType) {
+				audioPeriods[period] = struct{}{}
+			}
+		}
+	}
+
+	err := filterRepresentations(manifest, audioContentType, func(as *mpd.AdaptationSet, r *mpd.Representation) (bool, error) {
+		return !rejectsAudioChannels(filters, audioChannels(as, r), usesJOC(as, r)), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, period := range manifest.Periods {
+		if _, found := audioPeriods[period]; !found {
+			continue
+		}
+
+		removedAll := true
+		for _, as := range period.AdaptationSets {
+			if as.ContentType != nil && *as.ContentType == string(audioContentType) {
+				removedAll = false
+			}
+		}
+
+		if removedAll {
+			return errors.New("audio channels filter removes every audio track")
+		}
+	}
+
+	return nil
+}
+
+// audioChannels returns the channel count declared by the AudioChannelConfiguration of a
+// representation, falling back to the ones of its AdaptationSet, or 0 when it isn't declared
+func audioChannels(as *mpd.AdaptationSet, r *mpd.Representation) int {
+	if c := r.AudioChannelConfiguration; c != nil && c.SchemeIDURI != nil && c.Value != nil {
+		if channels := parseAudioChannelConfiguration(*c.SchemeIDURI, *c.Value); channels > 0 {
+			return channels
+		}
+	}
+
+	for _, c := range as.AudioChannelConfiguration {
+		if c.SchemeIDURI == nil || c.Value == nil {
+			continue
+		}
+
+		if channels := parseAudioChannelConfiguration(*c.SchemeIDURI, *c.Value); channels > 0 {
+			return channels
+		}
+	}
+
+	return 0
+}
+
+// parseAudioChannelConfiguration reads the channel count of an AudioChannelConfiguration value,
+// which depends on its scheme. It returns 0 for unknown schemes and invalid values
+func parseAudioChannelConfiguration(scheme, value string) int {
+	switch scheme {
+	case channelConfigurationScheme:
+		channels, _ := strconv.Atoi(value)
+		return channels
+	case cicpChannelConfigurationScheme:
+		index, _ := strconv.Atoi(value)
+		return cicpChannels[index]
+	case dolbyChannelConfigurationScheme, legacyDolbyChannelConfigurationScheme:
+		mask, err := strconv.ParseUint(value, 16, 16)
+		if err != nil {
+			return 0
+		}
+
+		// the mask flags channel locations from its most significant bit, some of them
+		// standing for a pair of channels
+		channels := 0
+		for i, locations := range dolbyChannelLocations {
+			if mask&(1<<uint(15-i)) != 0 {
+				channels += locations
+			}
+		}
+
+		return channels
+	}
+
+	return 0
+}
+
+// usesJOC returns true if a representation carries Dolby Digital Plus with joint object
+// coding (Dolby Atmos), as signaled by its codec or by the EC-3 extension type descriptor
+func usesJOC(as *mpd.AdaptationSet, r *mpd.Representation) bool {
+	codec := r.Codecs
+	if codec == nil {
+		codec = as.Codecs
+	}
+
+	if codec != nil && strings.EqualFold(*codec, "ec+3") {
+		return true
+	}
+
+	descriptors := [][]mpd.DescriptorType{
+		r.EssentialProperty, r.SupplementalProperty,
+		as.EssentialProperty, as.SupplementalProperty,
+	}
+
+	for _, properties := range descriptors {
+		for _, p := range properties {
+			if p.SchemeIDURI != nil && *p.SchemeIDURI == ec3ExtensionTypeScheme &&
+				p.Value != nil && strings.EqualFold(*p.Value, "JOC") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// filterRepresentations removes the representations of contentType AdaptationSets rejected by
+// keep, along with the AdaptationSets left without representations
+func filterRepresentations(manifest *mpd.MPD, contentType ContentType, keep func(*mpd.AdaptationSet, *mpd.Representation) (bool, error)) error {
+	for _, period := range manifest.Periods {
+		var filteredAdaptationSets []*mpd.AdaptationSet
+
+		for _, as := range period.AdaptationSets {
+			if as.ContentType == nil || *as.ContentType != string(contentType) {
+				filteredAdaptationSets = append(filteredAdaptationSets, as)
+				continue
+			}
+
+			var filteredRepresentations []*mpd.Representation
+			for _, r := range as.Representations {
+				kept, err := keep(as, r)
+				if err != nil {
+					return err
+				}
+
+				if kept {
 					filteredRepresentations = append(filteredRepresentations, r)
 				}
 			}
+
 			as.Representations = filteredRepresentations
 			if len(as.Representations) != 0 {
 				filteredAdaptationSets = append(filteredAdaptationSets, as)
@@ -234,6 +1377,30 @@ func (d *DASHFilter) filterBandwidth(filters *parsers.MediaFilters, manifest *mp
 			as.ID = strptr(strconv.Itoa(index))
 		}
 	}
+
+	return nil
+}
+
+// parseFrameRate parses a DASH frame rate, given either as a number (e.g. 25) or as
+// a fraction (e.g. 60000/1001)
+func parseFrameRate(frameRate string) (float64, error) {
+	parts := strings.SplitN(frameRate, "/", 2)
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing frame rate %q: %w", frameRate, err)
+	}
+
+	if len(parts) == 1 {
+		return rate, nil
+	}
+
+	divisor, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || divisor == 0 {
+		return 0, fmt.Errorf("parsing frame rate %q: invalid divisor", frameRate)
+	}
+
+	return rate / divisor, nil
 }
 
 func strptr(s string) *string {
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"math"
 	"testing"
+	"time"
 
+	"github.com/cbsinteractive/bakery/pkg/codecs"
 	"github.com/cbsinteractive/bakery/pkg/config"
 	"github.com/cbsinteractive/bakery/pkg/parsers"
 	"github.com/google/go-cmp/cmp"
@@ -328,56 +330,65 @@ func TestDASHFilter_FilterManifest_videoCodecs(t *testing.T) {
 	}
 }
 
-func TestDASHFilter_FilterManifest_audioCodecs(t *testing.T) {
-	manifestWithEAC3AndAC3AudioCodec := `<?xml version="1.0" encoding="UTF-8"?>
+func TestDASHFilter_FilterManifest_includeModeCodecs(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
   <Period>
     <AdaptationSet id="0" lang="en" contentType="video">
-      <Representation bandwidth="256" codecs="avc" id="0"></Representation>
+      <Representation bandwidth="2048" codecs="avc1.640028" id="0"></Representation>
+      <Representation bandwidth="4096" codecs="hvc1.2.4.L123.B0" id="1"></Representation>
     </AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="audio">
-      <Representation bandwidth="256" codecs="ec-3" id="0"></Representation>
-      <Representation bandwidth="256" codecs="ac-3" id="1"></Representation>
+    <AdaptationSet id="1" lang="en" contentType="video">
+      <Representation bandwidth="8192" codecs="dvh1.05.06" id="0"></Representation>
     </AdaptationSet>
-  </Period>
-</MPD>
-`
-
-	manifestWithoutAC3AudioCodec := `<?xml version="1.0" encoding="UTF-8"?>
-<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
-  <BaseURL>http://existing.base/url/</BaseURL>
-  <Period>
-    <AdaptationSet id="0" lang="en" contentType="video">
-      <Representation bandwidth="256" codecs="avc" id="0"></Representation>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Representation bandwidth="128" codecs="mp4a.40.2" id="0"></Representation>
+      <Representation bandwidth="384" codecs="ec-3" id="1"></Representation>
     </AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="audio">
-      <Representation bandwidth="256" codecs="ec-3" id="0"></Representation>
+    <AdaptationSet id="3" lang="en" contentType="text">
+      <Representation bandwidth="256" codecs="wvtt" id="0"></Representation>
+      <Representation bandwidth="256" codecs="stpp" id="1"></Representation>
     </AdaptationSet>
   </Period>
 </MPD>
 `
 
-	manifestWithoutEAC3AudioCodec := `<?xml version="1.0" encoding="UTF-8"?>
+	manifestIncludingAVCAndAAC := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
   <Period>
     <AdaptationSet id="0" lang="en" contentType="video">
-      <Representation bandwidth="256" codecs="avc" id="0"></Representation>
+      <Representation bandwidth="2048" codecs="avc1.640028" id="0"></Representation>
     </AdaptationSet>
     <AdaptationSet id="1" lang="en" contentType="audio">
-      <Representation bandwidth="256" codecs="ac-3" id="1"></Representation>
+      <Representation bandwidth="128" codecs="mp4a.40.2" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="text">
+      <Representation bandwidth="256" codecs="wvtt" id="0"></Representation>
+      <Representation bandwidth="256" codecs="stpp" id="1"></Representation>
     </AdaptationSet>
   </Period>
 </MPD>
 `
 
-	manifestWithoutAudio := `<?xml version="1.0" encoding="UTF-8"?>
+	manifestIncludingSTPP := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
   <Period>
     <AdaptationSet id="0" lang="en" contentType="video">
-      <Representation bandwidth="256" codecs="avc" id="0"></Representation>
+      <Representation bandwidth="2048" codecs="avc1.640028" id="0"></Representation>
+      <Representation bandwidth="4096" codecs="hvc1.2.4.L123.B0" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video">
+      <Representation bandwidth="8192" codecs="dvh1.05.06" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Representation bandwidth="128" codecs="mp4a.40.2" id="0"></Representation>
+      <Representation bandwidth="384" codecs="ec-3" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="en" contentType="text">
+      <Representation bandwidth="256" codecs="stpp" id="1"></Representation>
     </AdaptationSet>
   </Period>
 </MPD>
@@ -391,28 +402,26 @@ func TestDASHFilter_FilterManifest_audioCodecs(t *testing.T) {
 		expectErr             bool
 	}{
 		{
-			name:                  "when all codecs are applied, audio is stripped from a manifest",
-			filters:               &parsers.MediaFilters{Audios: []parsers.AudioType{"ac-3", "ec-3"}},
-			manifestContent:       manifestWithEAC3AndAC3AudioCodec,
-			expectManifestContent: manifestWithoutAudio,
-		},
-		{
-			name:                  "when an audio filter is supplied with Enhanced AC-3 codec, Enhanced AC-3 is stripped out",
-			filters:               &parsers.MediaFilters{Audios: []parsers.AudioType{"ec-3"}},
-			manifestContent:       manifestWithEAC3AndAC3AudioCodec,
-			expectManifestContent: manifestWithoutEAC3AudioCodec,
-		},
-		{
-			name:                  "when an audio filter is supplied with AC-3 codec, AC-3 is stripped out",
-			filters:               &parsers.MediaFilters{Audios: []parsers.AudioType{"ac-3"}},
-			manifestContent:       manifestWithEAC3AndAC3AudioCodec,
-			expectManifestContent: manifestWithoutAC3AudioCodec,
+			name: "when including avc video and mp4a audio, expect every other video and audio " +
+				"representation to be stripped out",
+			filters: &parsers.MediaFilters{
+				Videos:    []parsers.VideoType{"avc"},
+				VideoMode: parsers.FilterModeInclude,
+				Audios:    []parsers.AudioType{"mp4a"},
+				AudioMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestIncludingAVCAndAAC,
 		},
 		{
-			name:                  "when no audio filters are supplied, nothing is stripped from manifest",
-			filters:               &parsers.MediaFilters{},
-			manifestContent:       manifestWithEAC3AndAC3AudioCodec,
-			expectManifestContent: manifestWithEAC3AndAC3AudioCodec,
+			name: "when including stpp captions, expect every other caption representation to be " +
+				"stripped out",
+			filters: &parsers.MediaFilters{
+				CaptionTypes:    []parsers.CaptionType{"stpp"},
+				CaptionTypeMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestIncludingSTPP,
 		},
 	}
 
@@ -423,7 +432,7 @@ func TestDASHFilter_FilterManifest_audioCodecs(t *testing.T) {
 
 			manifest, err := filter.FilterManifest(tt.filters)
 			if err != nil && !tt.expectErr {
-				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
 				return
 			} else if err == nil && tt.expectErr {
 				t.Error("FilterManifest() expected an error, got nil")
@@ -431,53 +440,35 @@ func TestDASHFilter_FilterManifest_audioCodecs(t *testing.T) {
 			}
 
 			if g, e := manifest, tt.expectManifestContent; g != e {
-				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
-					cmp.Diff(g, e))
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
 			}
 		})
 	}
 }
 
-func TestDASHFilter_FilterManifest_captionTypes(t *testing.T) {
-	manifestWithWVTTAndSTPPCaptions := `<?xml version="1.0" encoding="UTF-8"?>
-<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
-  <BaseURL>http://existing.base/url/</BaseURL>
-  <Period>
-    <AdaptationSet id="7357" lang="en" contentType="text">
-      <Representation bandwidth="256" codecs="wvtt" id="subtitle_en"></Representation>
-      <Representation bandwidth="256" codecs="stpp" id="subtitle_en_ttml"></Representation>
-    </AdaptationSet>
-  </Period>
-</MPD>
-`
-
-	manifestWithoutSTPPCaptions := `<?xml version="1.0" encoding="UTF-8"?>
+func TestDASHFilter_FilterManifest_hevcSampleEntries(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
   <Period>
-    <AdaptationSet id="0" lang="en" contentType="text">
-      <Representation bandwidth="256" codecs="wvtt" id="subtitle_en"></Representation>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2048" codecs="avc1.640028" id="0"></Representation>
+      <Representation bandwidth="4096" codecs="hvc1.2.4.L123.B0" id="1"></Representation>
+      <Representation bandwidth="4096" codecs="hev1.2.4.L123.B0" id="2"></Representation>
     </AdaptationSet>
   </Period>
 </MPD>
 `
 
-	manifestWithoutWVTTCaptions := `<?xml version="1.0" encoding="UTF-8"?>
+	manifestWithoutHEVC := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
   <Period>
-    <AdaptationSet id="0" lang="en" contentType="text">
-      <Representation bandwidth="256" codecs="stpp" id="subtitle_en_ttml"></Representation>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2048" codecs="avc1.640028" id="0"></Representation>
     </AdaptationSet>
   </Period>
 </MPD>
-`
-
-	manifestWithoutCaptions := `<?xml version="1.0" encoding="UTF-8"?>
-<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
-  <BaseURL>http://existing.base/url/</BaseURL>
-  <Period></Period>
-</MPD>
 `
 
 	tests := []struct {
@@ -488,31 +479,16 @@ func TestDASHFilter_FilterManifest_captionTypes(t *testing.T) {
 		expectErr             bool
 	}{
 		{
-			name: "when all caption types are supplied, captions are stripped from a " +
-				"manifest",
-			filters:               &parsers.MediaFilters{CaptionTypes: []parsers.CaptionType{"stpp", "wvtt"}},
-			manifestContent:       manifestWithWVTTAndSTPPCaptions,
-			expectManifestContent: manifestWithoutCaptions,
-		},
-		{
-			name: "when a caption type filter is supplied with stpp only, webvtt captions are " +
-				"filtered out",
-			filters:               &parsers.MediaFilters{CaptionTypes: []parsers.CaptionType{"stpp"}},
-			manifestContent:       manifestWithWVTTAndSTPPCaptions,
-			expectManifestContent: manifestWithoutSTPPCaptions,
-		},
-		{
-			name: "when a caption type filter is supplied with wvtt only, stpp captions are " +
-				"filtered out",
-			filters:               &parsers.MediaFilters{CaptionTypes: []parsers.CaptionType{"wvtt"}},
-			manifestContent:       manifestWithWVTTAndSTPPCaptions,
-			expectManifestContent: manifestWithoutWVTTCaptions,
+			name:                  "when filtering hvc, expect both hvc1 and hev1 representations to be stripped out",
+			filters:               &parsers.MediaFilters{Videos: []parsers.VideoType{"hvc"}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutHEVC,
 		},
 		{
-			name:                  "when no filters are supplied, captions are not stripped from a manifest",
-			filters:               &parsers.MediaFilters{},
-			manifestContent:       manifestWithWVTTAndSTPPCaptions,
-			expectManifestContent: manifestWithWVTTAndSTPPCaptions,
+			name:                  "when filtering hevc, expect both hvc1 and hev1 representations to be stripped out",
+			filters:               &parsers.MediaFilters{Videos: []parsers.VideoType{"hevc"}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutHEVC,
 		},
 	}
 
@@ -523,7 +499,7 @@ func TestDASHFilter_FilterManifest_captionTypes(t *testing.T) {
 
 			manifest, err := filter.FilterManifest(tt.filters)
 			if err != nil && !tt.expectErr {
-				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
 				return
 			} else if err == nil && tt.expectErr {
 				t.Error("FilterManifest() expected an error, got nil")
@@ -531,77 +507,114 @@ func TestDASHFilter_FilterManifest_captionTypes(t *testing.T) {
 			}
 
 			if g, e := manifest, tt.expectManifestContent; g != e {
-				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
-					cmp.Diff(g, e))
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
 			}
 		})
 	}
 }
 
-func TestDASHFilter_FilterManifest_filterStreams(t *testing.T) {
-	manifestWithAudioAndVideoStreams := `<?xml version="1.0" encoding="UTF-8"?>
+func TestDASHFilter_FilterManifest_av1Ladder(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
-  <Period id="0">
-    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="3" lang="en" contentType="audio"></AdaptationSet>
-    <AdaptationSet id="4" lang="en" contentType="audio"></AdaptationSet>
-  </Period>
-  <Period id="1">
-    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="3" lang="en" contentType="audio"></AdaptationSet>
-    <AdaptationSet id="4" lang="en" contentType="audio"></AdaptationSet>
-  </Period>
-  <Period id="2">
-    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2200000" codecs="avc1.64001f" height="720" id="0" width="1280"></Representation>
+      <Representation bandwidth="5400000" codecs="avc1.640028" height="1080" id="1" width="1920"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video">
+      <Representation bandwidth="1200000" codecs="av01.0.05M.08" height="720" id="2" width="1280"></Representation>
+      <Representation bandwidth="3000000" codecs="av01.0.08M.08" height="1080" id="3" width="1920"></Representation>
+      <Representation bandwidth="9000000" codecs="av01.0.12M.10" height="2160" id="4" width="3840"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="5"></Representation>
+      <Representation bandwidth="96000" codecs="opus" id="6"></Representation>
+      <Representation bandwidth="768000" codecs="ac-4.02.01.01" id="7"></Representation>
+      <Representation bandwidth="1000000" codecs="fLaC" id="8"></Representation>
+    </AdaptationSet>
   </Period>
 </MPD>
 `
 
-	manifestWithOnlyAudioStreams := `<?xml version="1.0" encoding="UTF-8"?>
+	manifestWithoutAV1 := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
-  <Period id="0">
-    <AdaptationSet id="0" lang="en" contentType="audio"></AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="audio"></AdaptationSet>
-  </Period>
-  <Period id="1">
-    <AdaptationSet id="0" lang="en" contentType="audio"></AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="audio"></AdaptationSet>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2200000" codecs="avc1.64001f" height="720" id="0" width="1280"></Representation>
+      <Representation bandwidth="5400000" codecs="avc1.640028" height="1080" id="1" width="1920"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="5"></Representation>
+      <Representation bandwidth="96000" codecs="opus" id="6"></Representation>
+      <Representation bandwidth="768000" codecs="ac-4.02.01.01" id="7"></Representation>
+      <Representation bandwidth="1000000" codecs="fLaC" id="8"></Representation>
+    </AdaptationSet>
   </Period>
 </MPD>
 `
 
-	manifestWithOnlyVideoStreams := `<?xml version="1.0" encoding="UTF-8"?>
+	manifestWithOpusOnly := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
-  <Period id="0">
-    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
-  </Period>
-  <Period id="1">
-    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2200000" codecs="avc1.64001f" height="720" id="0" width="1280"></Representation>
+      <Representation bandwidth="5400000" codecs="avc1.640028" height="1080" id="1" width="1920"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video">
+      <Representation bandwidth="1200000" codecs="av01.0.05M.08" height="720" id="2" width="1280"></Representation>
+      <Representation bandwidth="3000000" codecs="av01.0.08M.08" height="1080" id="3" width="1920"></Representation>
+      <Representation bandwidth="9000000" codecs="av01.0.12M.10" height="2160" id="4" width="3840"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Representation bandwidth="96000" codecs="opus" id="6"></Representation>
+    </AdaptationSet>
   </Period>
-  <Period id="2">
-    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
-    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
+</MPD>
+`
+
+	manifestWithoutFLACAndAC4 := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2200000" codecs="avc1.64001f" height="720" id="0" width="1280"></Representation>
+      <Representation bandwidth="5400000" codecs="avc1.640028" height="1080" id="1" width="1920"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video">
+      <Representation bandwidth="1200000" codecs="av01.0.05M.08" height="720" id="2" width="1280"></Representation>
+      <Representation bandwidth="3000000" codecs="av01.0.08M.08" height="1080" id="3" width="1920"></Representation>
+      <Representation bandwidth="9000000" codecs="av01.0.12M.10" height="2160" id="4" width="3840"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="5"></Representation>
+      <Representation bandwidth="96000" codecs="opus" id="6"></Representation>
+    </AdaptationSet>
   </Period>
 </MPD>
 `
 
-	manifestWithoutStreams := `<?xml version="1.0" encoding="UTF-8"?>
+	manifestWithoutAV1Level5 := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2200000" codecs="avc1.64001f" height="720" id="0" width="1280"></Representation>
+      <Representation bandwidth="5400000" codecs="avc1.640028" height="1080" id="1" width="1920"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video">
+      <Representation bandwidth="1200000" codecs="av01.0.05M.08" height="720" id="2" width="1280"></Representation>
+      <Representation bandwidth="3000000" codecs="av01.0.08M.08" height="1080" id="3" width="1920"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="5"></Representation>
+      <Representation bandwidth="96000" codecs="opus" id="6"></Representation>
+      <Representation bandwidth="768000" codecs="ac-4.02.01.01" id="7"></Representation>
+      <Representation bandwidth="1000000" codecs="fLaC" id="8"></Representation>
+    </AdaptationSet>
+  </Period>
 </MPD>
 `
 
@@ -610,31 +623,35 @@ func TestDASHFilter_FilterManifest_filterStreams(t *testing.T) {
 		filters               *parsers.MediaFilters
 		manifestContent       string
 		expectManifestContent string
+		expectErr             bool
 	}{
 		{
-			name:                  "when no streams are configured to be filtered, the manifest is not modified",
-			filters:               &parsers.MediaFilters{},
-			manifestContent:       manifestWithAudioAndVideoStreams,
-			expectManifestContent: manifestWithAudioAndVideoStreams,
+			name:                  "when filtering av1, expect the av1 adaptation set to be stripped out",
+			filters:               &parsers.MediaFilters{Videos: []parsers.VideoType{"av1"}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutAV1,
 		},
 		{
-			name:                  "when video streams are filtered, the manifest contains no video adaptation sets",
-			filters:               &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"video"}},
-			manifestContent:       manifestWithAudioAndVideoStreams,
-			expectManifestContent: manifestWithOnlyAudioStreams,
+			name: "when only opus is included, expect every other audio representation to be " +
+				"stripped out",
+			filters: &parsers.MediaFilters{
+				Audios:    []parsers.AudioType{"opus"},
+				AudioMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithOpusOnly,
 		},
 		{
-			name:                  "when audio streams are filtered, the manifest contains no audio adaptation sets",
-			filters:               &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"audio"}},
-			manifestContent:       manifestWithAudioAndVideoStreams,
-			expectManifestContent: manifestWithOnlyVideoStreams,
+			name:                  "when filtering flac and ac-4, expect their representations to be stripped out",
+			filters:               &parsers.MediaFilters{Audios: []parsers.AudioType{"flac", "ac-4"}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutFLACAndAC4,
 		},
 		{
-			name: "when audio and video streams are filtered, the manifest contains no audio or " +
-				"video adaptation sets",
-			filters:               &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"video", "audio"}},
-			manifestContent:       manifestWithAudioAndVideoStreams,
-			expectManifestContent: manifestWithoutStreams,
+			name:                  "when capping the av1 level, expect av1 representations above it to be stripped out",
+			filters:               &parsers.MediaFilters{VideoLevels: map[codecs.Family]float64{codecs.FamilyAV1: 4.1}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutAV1Level5,
 		},
 	}
 
@@ -644,51 +661,1707 @@ func TestDASHFilter_FilterManifest_filterStreams(t *testing.T) {
 			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
 
 			manifest, err := filter.FilterManifest(tt.filters)
-			if err != nil {
-				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
 				return
 			}
 
 			if g, e := manifest, tt.expectManifestContent; g != e {
-				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
-					cmp.Diff(g, e))
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
 			}
 		})
 	}
 }
 
-func TestDASHFilter_FilterManifest_bitrate(t *testing.T) {
+func TestDASHFilter_FilterManifest_codecCaps(t *testing.T) {
 	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
   <Period>
     <AdaptationSet id="0" lang="en" contentType="video">
-      <Representation bandwidth="2048" codecs="avc" id="0"></Representation>
-      <Representation bandwidth="4096" codecs="avc" id="1"></Representation>
+      <Representation bandwidth="2048" codecs="avc1.4d401f" id="0"></Representation>
+      <Representation bandwidth="8192" codecs="avc1.640033" id="1"></Representation>
     </AdaptationSet>
-    <AdaptationSet id="1" lang="en" contentType="audio">
-      <Representation bandwidth="256" codecs="ac-3" id="0"></Representation>
+    <AdaptationSet codecs="hvc1.2.4.L153.B0" id="1" lang="en" contentType="video">
+      <Representation bandwidth="6144" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="mp4a.40.2" id="0"></Representation>
     </AdaptationSet>
   </Period>
 </MPD>
 `
 
-	manifestFiltering256And2048Representations := `<?xml version="1.0" encoding="UTF-8"?>
+	manifestForLegacyDevices := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
   <Period>
     <AdaptationSet id="0" lang="en" contentType="video">
-      <Representation bandwidth="4096" codecs="avc" id="1"></Representation>
+      <Representation bandwidth="2048" codecs="avc1.4d401f" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="mp4a.40.2" id="0"></Representation>
     </AdaptationSet>
   </Period>
 </MPD>
 `
 
-	manifestFiltering4096Representation := `<?xml version="1.0" encoding="UTF-8"?>
-<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
-  <BaseURL>http://existing.base/url/</BaseURL>
-  <Period>
-    <AdaptationSet id="0" lang="en" contentType="video">
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when capping avc level and hevc profile, expect representations exceeding either to be " +
+				"stripped out, using the codecs of the adaptation set when representations have none",
+			filters: &parsers.MediaFilters{
+				VideoLevels:   map[codecs.Family]float64{codecs.FamilyAVC: 4},
+				VideoProfiles: map[codecs.Family][]string{codecs.FamilyHEVC: {"main"}},
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestForLegacyDevices,
+		},
+		{
+			name:                  "when capping at the highest levels in the manifest, nothing is stripped from manifest",
+			filters:               &parsers.MediaFilters{VideoLevels: map[codecs.Family]float64{codecs.FamilyAVC: 5.1, codecs.FamilyHEVC: 5.1}},
+			manifestContent:       baseManifest,
+			expectManifestContent: baseManifest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_dynamicRange(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video">
+      <SupplementalProperty schemeIdUri="urn:mpeg:mpegB:cicp:TransferCharacteristics" value="16"></SupplementalProperty>
+      <Representation bandwidth="8000000" codecs="hvc1.2.4.L150.B0" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="video">
+      <EssentialProperty schemeIdUri="urn:mpeg:mpegB:cicp:TransferCharacteristics" value="18"></EssentialProperty>
+      <Representation bandwidth="8000000" codecs="hvc1.2.4.L150.B0" id="2"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="en" contentType="video">
+      <SupplementalProperty schemeIdUri="urn:mpeg:mpegB:cicp:TransferCharacteristics" value="16"></SupplementalProperty>
+      <Representation bandwidth="9000000" codecs="dvh1.05.06" id="3"></Representation>
+      <Representation bandwidth="9000000" codecs="dvh1.08.06" id="4"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="4" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="5"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithSDROnly := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="5"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutDolbyVisionOnly := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video">
+      <SupplementalProperty schemeIdUri="urn:mpeg:mpegB:cicp:TransferCharacteristics" value="16"></SupplementalProperty>
+      <Representation bandwidth="8000000" codecs="hvc1.2.4.L150.B0" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="video">
+      <EssentialProperty schemeIdUri="urn:mpeg:mpegB:cicp:TransferCharacteristics" value="18"></EssentialProperty>
+      <Representation bandwidth="8000000" codecs="hvc1.2.4.L150.B0" id="2"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="en" contentType="video">
+      <SupplementalProperty schemeIdUri="urn:mpeg:mpegB:cicp:TransferCharacteristics" value="16"></SupplementalProperty>
+      <Representation bandwidth="9000000" codecs="dvh1.08.06" id="4"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="4" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="5"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithHLGOnly := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <EssentialProperty schemeIdUri="urn:mpeg:mpegB:cicp:TransferCharacteristics" value="18"></EssentialProperty>
+      <Representation bandwidth="8000000" codecs="hvc1.2.4.L150.B0" id="2"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="5"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when filtering every hdr range, expect only sdr representations to be kept",
+			filters: &parsers.MediaFilters{DynamicRanges: []parsers.DynamicRange{
+				parsers.DynamicRangePQ, parsers.DynamicRangeHLG, parsers.DynamicRangeDolbyVision,
+			}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithSDROnly,
+		},
+		{
+			name: "when filtering dolby vision, expect profile 5 representations to be stripped out and " +
+				"cross compatible profile 8.1 representations to be kept",
+			filters:               &parsers.MediaFilters{DynamicRanges: []parsers.DynamicRange{parsers.DynamicRangeDolbyVision}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutDolbyVisionOnly,
+		},
+		{
+			name: "when only hlg is included, expect representations signaling hlg through an essential " +
+				"property to be kept",
+			filters: &parsers.MediaFilters{
+				DynamicRanges:    []parsers.DynamicRange{parsers.DynamicRangeHLG},
+				DynamicRangeMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithHLGOnly,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_audioCodecs(t *testing.T) {
+	manifestWithEAC3AndAC3AudioCodec := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="256" codecs="avc" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="ec-3" id="0"></Representation>
+      <Representation bandwidth="256" codecs="ac-3" id="1"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutAC3AudioCodec := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="256" codecs="avc" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="ec-3" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutEAC3AudioCodec := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="256" codecs="avc" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="ac-3" id="1"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutAudio := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="256" codecs="avc" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when all codecs are applied, audio is stripped from a manifest",
+			filters:               &parsers.MediaFilters{Audios: []parsers.AudioType{"ac-3", "ec-3"}},
+			manifestContent:       manifestWithEAC3AndAC3AudioCodec,
+			expectManifestContent: manifestWithoutAudio,
+		},
+		{
+			name:                  "when an audio filter is supplied with Enhanced AC-3 codec, Enhanced AC-3 is stripped out",
+			filters:               &parsers.MediaFilters{Audios: []parsers.AudioType{"ec-3"}},
+			manifestContent:       manifestWithEAC3AndAC3AudioCodec,
+			expectManifestContent: manifestWithoutEAC3AudioCodec,
+		},
+		{
+			name:                  "when an audio filter is supplied with AC-3 codec, AC-3 is stripped out",
+			filters:               &parsers.MediaFilters{Audios: []parsers.AudioType{"ac-3"}},
+			manifestContent:       manifestWithEAC3AndAC3AudioCodec,
+			expectManifestContent: manifestWithoutAC3AudioCodec,
+		},
+		{
+			name:                  "when no audio filters are supplied, nothing is stripped from manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithEAC3AndAC3AudioCodec,
+			expectManifestContent: manifestWithEAC3AndAC3AudioCodec,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_audioChannels(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1">
+        <AudioChannelConfiguration schemeIdUri="urn:mpeg:dash:23003:3:audio_channel_configuration:2011" value="2"></AudioChannelConfiguration>
+      </Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <AudioChannelConfiguration schemeIdUri="tag:dolby.com,2014:dash:audio_channel_configuration:2011" value="F801"></AudioChannelConfiguration>
+      <Representation bandwidth="384000" codecs="ec-3" id="2"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="en" contentType="audio">
+      <AudioChannelConfiguration schemeIdUri="tag:dolby.com,2014:dash:audio_channel_configuration:2011" value="F801"></AudioChannelConfiguration>
+      <SupplementalProperty schemeIdUri="tag:dolby.com,2018:dash:EC3_ExtensionType:2018" value="JOC"></SupplementalProperty>
+      <Representation bandwidth="768000" codecs="ec-3" id="3"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutJOC := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1">
+        <AudioChannelConfiguration schemeIdUri="urn:mpeg:dash:23003:3:audio_channel_configuration:2011" value="2"></AudioChannelConfiguration>
+      </Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <AudioChannelConfiguration schemeIdUri="tag:dolby.com,2014:dash:audio_channel_configuration:2011" value="F801"></AudioChannelConfiguration>
+      <Representation bandwidth="384000" codecs="ec-3" id="2"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithStereoOnly := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1">
+        <AudioChannelConfiguration schemeIdUri="urn:mpeg:dash:23003:3:audio_channel_configuration:2011" value="2"></AudioChannelConfiguration>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when excluding joint object coding, expect the atmos adaptation set to be stripped out",
+			filters:               &parsers.MediaFilters{ExcludeJOC: true},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutJOC,
+		},
+		{
+			name: "when keeping stereo only, expect adaptation sets with a 5.1 dolby channel mask to be " +
+				"stripped out",
+			filters:               &parsers.MediaFilters{MaxAudioChannels: 2},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithStereoOnly,
+		},
+		{
+			name:                  "when capping channels to 5.1, expect every adaptation set to be kept",
+			filters:               &parsers.MediaFilters{MaxAudioChannels: 6},
+			manifestContent:       baseManifest,
+			expectManifestContent: baseManifest,
+		},
+		{
+			name:            "when every audio representation is stripped out, expect an error",
+			filters:         &parsers.MediaFilters{MaxAudioChannels: 1},
+			manifestContent: baseManifest,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_audioDescription(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="alternate"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="2"></Representation>
+      <Accessibility schemeIdUri="urn:tva:metadata:cs:AudioPurposeCS:2007" value="1"></Accessibility>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:tva:metadata:cs:AudioPurposeCS:2007" value="1"></Role>
+      <Representation bandwidth="128000" codecs="ec-3" id="3"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutAudioDescription := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithAudioDescriptionOnly := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="alternate"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="2"></Representation>
+      <Accessibility schemeIdUri="urn:tva:metadata:cs:AudioPurposeCS:2007" value="1"></Accessibility>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:tva:metadata:cs:AudioPurposeCS:2007" value="1"></Role>
+      <Representation bandwidth="128000" codecs="ec-3" id="3"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when excluding audio description, expect adaptation sets signaling it through " +
+				"accessibility or role descriptors to be stripped out",
+			filters:               &parsers.MediaFilters{AudioDescription: parsers.AudioDescriptionExclude},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutAudioDescription,
+		},
+		{
+			name:                  "when keeping audio description only, expect every other audio adaptation set to be stripped out",
+			filters:               &parsers.MediaFilters{AudioDescription: parsers.AudioDescriptionOnly},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithAudioDescriptionOnly,
+		},
+		{
+			name:            "when keeping audio description only and there is none, expect an error",
+			filters:         &parsers.MediaFilters{AudioDescription: parsers.AudioDescriptionOnly},
+			manifestContent: manifestWithoutAudioDescription,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_roles(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="commentary"></Role>
+      <Representation bandwidth="96000" codecs="mp4a.40.2" id="2"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="es" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="dub"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="3"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="4" lang="en" contentType="text">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="subtitle"></Role>
+      <Representation bandwidth="256" codecs="wvtt" id="4"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="5" lang="en" contentType="text">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="forced-subtitle"></Role>
+      <Representation bandwidth="256" codecs="wvtt" id="5"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutCommentary := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="es" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="dub"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="3"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="en" contentType="text">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="subtitle"></Role>
+      <Representation bandwidth="256" codecs="wvtt" id="4"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="4" lang="en" contentType="text">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="forced-subtitle"></Role>
+      <Representation bandwidth="256" codecs="wvtt" id="5"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithMainAndSubtitles := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="text">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="subtitle"></Role>
+      <Representation bandwidth="256" codecs="wvtt" id="4"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithMainAndForcedSubtitles := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="text">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="subtitle"></Role>
+      <Representation bandwidth="256" codecs="wvtt" id="4"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="en" contentType="text">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="forced-subtitle"></Role>
+      <Representation bandwidth="256" codecs="wvtt" id="5"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithForcedSubtitlesOnly := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="commentary"></Role>
+      <Representation bandwidth="96000" codecs="mp4a.40.2" id="2"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="es" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="dub"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="3"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="4" lang="en" contentType="text">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="forced-subtitle"></Role>
+      <Representation bandwidth="256" codecs="wvtt" id="5"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithMainAudio := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="text">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"></Role>
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="subtitle"></Role>
+      <Representation bandwidth="256" codecs="wvtt" id="4"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="en" contentType="text">
+      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="forced-subtitle"></Role>
+      <Representation bandwidth="256" codecs="wvtt" id="5"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when filtering commentary, expect commentary adaptation sets to be stripped out",
+			filters:               &parsers.MediaFilters{Roles: []parsers.Role{"commentary"}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutCommentary,
+		},
+		{
+			name: "when only main is included, expect adaptation sets without a role to be kept as main " +
+				"ones, every other audio and video role to be stripped out and text adaptation sets to be kept",
+			filters: &parsers.MediaFilters{
+				Roles:    []parsers.Role{"main"},
+				RoleMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithMainAudio,
+		},
+		{
+			name: "when main and subtitles are included, expect other text roles to be stripped out",
+			filters: &parsers.MediaFilters{
+				Roles:    []parsers.Role{"main", "subtitle"},
+				RoleMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithMainAndSubtitles,
+		},
+		{
+			name: "when main and forced subtitles are included, expect forced subtitles to be kept",
+			filters: &parsers.MediaFilters{
+				Roles:    []parsers.Role{"main", "forced-subtitle"},
+				RoleMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithMainAndForcedSubtitles,
+		},
+		{
+			name: "when only text roles are included, expect audio and video adaptation sets to be kept",
+			filters: &parsers.MediaFilters{
+				Roles:    []parsers.Role{"forced-subtitle"},
+				RoleMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithForcedSubtitlesOnly,
+		},
+		{
+			name: "when every audio adaptation set is stripped out, expect an error",
+			filters: &parsers.MediaFilters{
+				Roles:    []parsers.Role{"alternate"},
+				RoleMode: parsers.FilterModeInclude,
+			},
+			manifestContent: baseManifest,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_closedCaptions(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+      <Accessibility schemeIdUri="urn:scte:dash:cc:cea-608:2015" value="CC1=eng;CC3=spa"></Accessibility>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutSpanish := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+      <Accessibility schemeIdUri="urn:scte:dash:cc:cea-608:2015" value="CC1=eng"></Accessibility>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutClosedCaptions := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="128000" codecs="mp4a.40.2" id="1"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when filtering a channel, expect it to be stripped out of the cea-608 descriptor",
+			filters:               &parsers.MediaFilters{ClosedCaptions: []parsers.ClosedCaption{"CC3"}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutSpanish,
+		},
+		{
+			name:                  "when filtering a language, expect its channel to be stripped out of the cea-608 descriptor",
+			filters:               &parsers.MediaFilters{ClosedCaptions: []parsers.ClosedCaption{"spa"}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutSpanish,
+		},
+		{
+			name:                  "when filtering every channel, expect the cea-608 descriptor to be stripped out",
+			filters:               &parsers.MediaFilters{ClosedCaptions: []parsers.ClosedCaption{"CC1", "CC3"}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutClosedCaptions,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_drmSystems(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <ContentProtection schemeIdUri="urn:mpeg:dash:mp4protection:2011" cenc:default_KID="80399bf5-8a21-4014-8053-e27e748e98c0" value="cenc"></ContentProtection>
+      <ContentProtection schemeIdUri="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed">
+        <cenc:pssh>AAAAW3Bzc2g=</cenc:pssh>
+      </ContentProtection>
+      <ContentProtection schemeIdUri="urn:uuid:9a04f079-9840-4286-ab92-e65be0885f95">
+        <cenc:pssh>AAADfnBzc2g=</cenc:pssh>
+      </ContentProtection>
+      <ContentProtection schemeIdUri="urn:uuid:e2719d58-a985-b3c9-781a-b030af78d30e"></ContentProtection>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithWidevine := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <ContentProtection schemeIdUri="urn:mpeg:dash:mp4protection:2011" cenc:default_KID="80399bf5-8a21-4014-8053-e27e748e98c0" value="cenc"></ContentProtection>
+      <ContentProtection schemeIdUri="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed">
+        <cenc:pssh>AAAAW3Bzc2g=</cenc:pssh>
+      </ContentProtection>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutWidevine := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <ContentProtection schemeIdUri="urn:mpeg:dash:mp4protection:2011" cenc:default_KID="80399bf5-8a21-4014-8053-e27e748e98c0" value="cenc"></ContentProtection>
+      <ContentProtection schemeIdUri="urn:uuid:9a04f079-9840-4286-ab92-e65be0885f95">
+        <cenc:pssh>AAADfnBzc2g=</cenc:pssh>
+      </ContentProtection>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when keeping a drm system, expect the content protection of every other system to be " +
+				"stripped out and the common encryption one to be kept",
+			filters:               &parsers.MediaFilters{DRMSystems: []parsers.DRMSystem{parsers.DRMSystemWidevine}},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithWidevine,
+		},
+		{
+			name: "when keeping a drm system missing from the manifest, expect the content protection of the " +
+				"others to be stripped out",
+			filters: &parsers.MediaFilters{
+				DRMSystems: []parsers.DRMSystem{parsers.DRMSystemPlayReady, parsers.DRMSystemFairPlay},
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithoutWidevine,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_licenseURLs(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <ContentProtection schemeIdUri="urn:mpeg:dash:mp4protection:2011" cenc:default_KID="80399bf5-8a21-4014-8053-e27e748e98c0" value="cenc"></ContentProtection>
+      <ContentProtection schemeIdUri="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed">
+        <cenc:pssh>AAAAW3Bzc2g=</cenc:pssh>
+      </ContentProtection>
+      <ContentProtection schemeIdUri="urn:uuid:9a04f079-9840-4286-ab92-e65be0885f95">
+        <ms:laurl licenseUrl="https://playready.example.com/rightsmanager.asmx"></ms:laurl>
+        <cenc:pssh>AAADfnBzc2g=</cenc:pssh>
+      </ContentProtection>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithLicenseURLs := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <ContentProtection schemeIdUri="urn:mpeg:dash:mp4protection:2011" cenc:default_KID="80399bf5-8a21-4014-8053-e27e748e98c0" value="cenc"></ContentProtection>
+      <ContentProtection schemeIdUri="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed">
+        <dashif:laurl xmlns:dashif="https://dashif.org/CPS">https://license.proxy/widevine</dashif:laurl>
+        <cenc:pssh>AAAAW3Bzc2g=</cenc:pssh>
+      </ContentProtection>
+      <ContentProtection schemeIdUri="urn:uuid:9a04f079-9840-4286-ab92-e65be0885f95">
+        <dashif:laurl xmlns:dashif="https://dashif.org/CPS">https://license.proxy/playready</dashif:laurl>
+        <ms:laurl xmlns:ms="urn:microsoft" licenseUrl="https://license.proxy/playready"></ms:laurl>
+        <cenc:pssh>AAADfnBzc2g=</cenc:pssh>
+      </ContentProtection>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		manifestURL           string
+		manifestContent       string
+		expectManifestContent string
+	}{
+		{
+			name: "when a license url is configured for the origin, expect it to be declared by the content " +
+				"protection of every drm system, replacing the ones they declared",
+			manifestURL:           "http://existing.base/url/manifest.mpd",
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestWithLicenseURLs,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.Config{
+				LicenseURLs: config.LicenseURLTemplates{"existing.base": "https://license.proxy/{drm}"},
+			}
+			filter := NewDASHFilter(tt.manifestURL, tt.manifestContent, c)
+
+			manifest, err := filter.FilterManifest(&parsers.MediaFilters{})
+			if err != nil {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_timeClip(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" mediaPresentationDuration="PT22S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" timescale="1000">
+        <SegmentTimeline>
+          <S t="0" d="4000" r="4"></S>
+          <S d="2000"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestClipped := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" mediaPresentationDuration="PT8S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate presentationTimeOffset="5000" initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="2" timescale="1000">
+        <SegmentTimeline>
+          <S t="4000" d="4000" r="2"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestClippedToEnd := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" mediaPresentationDuration="PT4S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate presentationTimeOffset="18000" initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="5" timescale="1000">
+        <SegmentTimeline>
+          <S t="16000" d="4000"></S>
+          <S d="2000"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	numberedManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" mediaPresentationDuration="PT22S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate duration="4000" initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="1" timescale="1000"></SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	numberedManifestClipped := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" mediaPresentationDuration="PT7S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate presentationTimeOffset="8000" duration="4000" initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="3" timescale="1000"></SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	numberedManifestClippedBetweenBoundaries := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" mediaPresentationDuration="PT11S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate presentationTimeOffset="4000" duration="4000" initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="2" timescale="1000"></SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	dynamicManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="dynamic" mediaPresentationDuration="PT22S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" timescale="1000">
+        <SegmentTimeline>
+          <S t="0" d="4000" r="4"></S>
+          <S d="2000"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when clipping a segment timeline, expect the segments covering the clip to be kept and the " +
+				"presentation to start at the start of the clip",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 5 * time.Second, End: 13 * time.Second},
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestClipped,
+		},
+		{
+			name: "when clipping past the end of the presentation, expect the clip to end with the presentation",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 18 * time.Second, End: 30 * time.Second},
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestClippedToEnd,
+		},
+		{
+			name: "when clipping segments addressed by their duration, expect the clip to start at a segment " +
+				"boundary",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 9 * time.Second, End: 15 * time.Second},
+			},
+			manifestContent:       numberedManifest,
+			expectManifestContent: numberedManifestClipped,
+		},
+		{
+			name: "when clipping segments addressed by their duration from between segment boundaries, expect " +
+				"the presentation to last until the end of the clip",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 5 * time.Second, End: 15 * time.Second},
+			},
+			manifestContent:       numberedManifest,
+			expectManifestContent: numberedManifestClippedBetweenBoundaries,
+		},
+		{
+			name: "when clipping after the end of the presentation, expect an error",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 30 * time.Second, End: 40 * time.Second},
+			},
+			manifestContent: baseManifest,
+			expectErr:       true,
+		},
+		{
+			name: "when clipping a dynamic manifest, expect an error",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 5 * time.Second, End: 13 * time.Second},
+			},
+			manifestContent: dynamicManifest,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_dvrWindow(t *testing.T) {
+	liveManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="dynamic" minBufferTime="PT2S" timeShiftBufferDepth="PT1M">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="25" timescale="1000">
+        <SegmentTimeline>
+          <S t="100000" d="4000" r="9"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	trimmedManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="dynamic" minBufferTime="PT2S" timeShiftBufferDepth="PT10S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="32" timescale="1000">
+        <SegmentTimeline>
+          <S t="128000" d="4000" r="2"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	shortWindowManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="dynamic" minBufferTime="PT2S" timeShiftBufferDepth="PT5S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="25" timescale="1000">
+        <SegmentTimeline>
+          <S t="100000" d="4000" r="9"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	repeatedUntilNextEntryManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="dynamic" minBufferTime="PT2S" timeShiftBufferDepth="PT1M">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="25" timescale="1000">
+        <SegmentTimeline>
+          <S t="100000" d="4000" r="-1"></S>
+          <S t="124000" d="2000" r="4"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	repeatedUntilNextEntryTrimmedManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="dynamic" minBufferTime="PT2S" timeShiftBufferDepth="PT10S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="31" timescale="1000">
+        <SegmentTimeline>
+          <S t="124000" d="2000" r="4"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	repeatedUntilPeriodEndManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="dynamic" minBufferTime="PT2S" timeShiftBufferDepth="PT1M">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="25" timescale="1000">
+        <SegmentTimeline>
+          <S t="100000" d="4000" r="-1"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	repeatedUntilPeriodEndShortenedManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="dynamic" minBufferTime="PT2S" timeShiftBufferDepth="PT10S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="25" timescale="1000">
+        <SegmentTimeline>
+          <S t="100000" d="4000" r="-1"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	staticManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static" minBufferTime="PT2S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <SegmentTemplate initialization="$RepresentationID$/init.mp4" media="$RepresentationID$/$Number$.m4s" startNumber="25" timescale="1000">
+        <SegmentTimeline>
+          <S t="100000" d="4000" r="9"></S>
+        </SegmentTimeline>
+      </SegmentTemplate>
+      <Representation bandwidth="5000000" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when shortening the dvr window of a dynamic manifest, expect the time shift buffer depth " +
+				"to be rewritten and the oldest segments to be stripped out",
+			filters:               &parsers.MediaFilters{DVRWindow: 10 * time.Second},
+			manifestContent:       liveManifest,
+			expectManifestContent: trimmedManifest,
+		},
+		{
+			name:                  "when the time shift buffer depth is shorter than the dvr window, expect it to be kept",
+			filters:               &parsers.MediaFilters{DVRWindow: 10 * time.Second},
+			manifestContent:       shortWindowManifest,
+			expectManifestContent: shortWindowManifest,
+		},
+		{
+			name: "when shortening the dvr window of a timeline repeating an entry until the next one, expect " +
+				"the repeated segments to be stripped out",
+			filters:               &parsers.MediaFilters{DVRWindow: 10 * time.Second},
+			manifestContent:       repeatedUntilNextEntryManifest,
+			expectManifestContent: repeatedUntilNextEntryTrimmedManifest,
+		},
+		{
+			name: "when shortening the dvr window of a timeline repeating its last entry until the end of the " +
+				"period, expect only the time shift buffer depth to be rewritten",
+			filters:               &parsers.MediaFilters{DVRWindow: 10 * time.Second},
+			manifestContent:       repeatedUntilPeriodEndManifest,
+			expectManifestContent: repeatedUntilPeriodEndShortenedManifest,
+		},
+		{
+			name:                  "when setting the dvr window of a static manifest, expect it to be left as it is",
+			filters:               &parsers.MediaFilters{DVRWindow: 10 * time.Second},
+			manifestContent:       staticManifest,
+			expectManifestContent: staticManifest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_captionTypes(t *testing.T) {
+	manifestWithWVTTAndSTPPCaptions := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="7357" lang="en" contentType="text">
+      <Representation bandwidth="256" codecs="wvtt" id="subtitle_en"></Representation>
+      <Representation bandwidth="256" codecs="stpp" id="subtitle_en_ttml"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutSTPPCaptions := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="text">
+      <Representation bandwidth="256" codecs="wvtt" id="subtitle_en"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutWVTTCaptions := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="text">
+      <Representation bandwidth="256" codecs="stpp" id="subtitle_en_ttml"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutCaptions := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period></Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when all caption types are supplied, captions are stripped from a " +
+				"manifest",
+			filters:               &parsers.MediaFilters{CaptionTypes: []parsers.CaptionType{"stpp", "wvtt"}},
+			manifestContent:       manifestWithWVTTAndSTPPCaptions,
+			expectManifestContent: manifestWithoutCaptions,
+		},
+		{
+			name: "when a caption type filter is supplied with stpp only, webvtt captions are " +
+				"filtered out",
+			filters:               &parsers.MediaFilters{CaptionTypes: []parsers.CaptionType{"stpp"}},
+			manifestContent:       manifestWithWVTTAndSTPPCaptions,
+			expectManifestContent: manifestWithoutSTPPCaptions,
+		},
+		{
+			name: "when a caption type filter is supplied with wvtt only, stpp captions are " +
+				"filtered out",
+			filters:               &parsers.MediaFilters{CaptionTypes: []parsers.CaptionType{"wvtt"}},
+			manifestContent:       manifestWithWVTTAndSTPPCaptions,
+			expectManifestContent: manifestWithoutWVTTCaptions,
+		},
+		{
+			name:                  "when no filters are supplied, captions are not stripped from a manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithWVTTAndSTPPCaptions,
+			expectManifestContent: manifestWithWVTTAndSTPPCaptions,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_filterStreams(t *testing.T) {
+	manifestWithAudioAndVideoStreams := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period id="0">
+    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="3" lang="en" contentType="audio"></AdaptationSet>
+    <AdaptationSet id="4" lang="en" contentType="audio"></AdaptationSet>
+  </Period>
+  <Period id="1">
+    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="3" lang="en" contentType="audio"></AdaptationSet>
+    <AdaptationSet id="4" lang="en" contentType="audio"></AdaptationSet>
+  </Period>
+  <Period id="2">
+    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithOnlyAudioStreams := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period id="0">
+    <AdaptationSet id="0" lang="en" contentType="audio"></AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio"></AdaptationSet>
+  </Period>
+  <Period id="1">
+    <AdaptationSet id="0" lang="en" contentType="audio"></AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio"></AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithOnlyVideoStreams := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period id="0">
+    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
+  </Period>
+  <Period id="1">
+    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
+  </Period>
+  <Period id="2">
+    <AdaptationSet id="0" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="video"></AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="video"></AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutStreams := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+	}{
+		{
+			name:                  "when no streams are configured to be filtered, the manifest is not modified",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithAudioAndVideoStreams,
+			expectManifestContent: manifestWithAudioAndVideoStreams,
+		},
+		{
+			name:                  "when video streams are filtered, the manifest contains no video adaptation sets",
+			filters:               &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"video"}},
+			manifestContent:       manifestWithAudioAndVideoStreams,
+			expectManifestContent: manifestWithOnlyAudioStreams,
+		},
+		{
+			name:                  "when audio streams are filtered, the manifest contains no audio adaptation sets",
+			filters:               &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"audio"}},
+			manifestContent:       manifestWithAudioAndVideoStreams,
+			expectManifestContent: manifestWithOnlyVideoStreams,
+		},
+		{
+			name: "when audio and video streams are filtered, the manifest contains no audio or " +
+				"video adaptation sets",
+			filters:               &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"video", "audio"}},
+			manifestContent:       manifestWithAudioAndVideoStreams,
+			expectManifestContent: manifestWithoutStreams,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_bitrate(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2048" codecs="avc" id="0"></Representation>
+      <Representation bandwidth="4096" codecs="avc" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="ac-3" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestFiltering256And2048Representations := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="4096" codecs="avc" id="1"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestFiltering4096Representation := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
       <Representation bandwidth="2048" codecs="avc" id="0"></Representation>
     </AdaptationSet>
     <AdaptationSet id="1" lang="en" contentType="audio">
@@ -698,15 +2371,387 @@ func TestDASHFilter_FilterManifest_bitrate(t *testing.T) {
 </MPD>
 `
 
-	manifestFiltering2048And4096Representations := `<?xml version="1.0" encoding="UTF-8"?>
+	manifestFiltering2048And4096Representations := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="ac-3" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when no filters are given, nothing is stripped from manifest",
+			filters:               &parsers.MediaFilters{MinBitrate: 0, MaxBitrate: math.MaxInt32},
+			manifestContent:       baseManifest,
+			expectManifestContent: baseManifest,
+		},
+		{
+			name:                  "when negative bitrates are entered, nothing is stripped from manifest",
+			filters:               &parsers.MediaFilters{MinBitrate: -100, MaxBitrate: -10},
+			manifestContent:       baseManifest,
+			expectManifestContent: baseManifest,
+		},
+		{
+			name:                  "when both bounds are exceeeded, expect nothing is stripped from manifest",
+			filters:               &parsers.MediaFilters{MinBitrate: -100, MaxBitrate: math.MaxInt32 + 1},
+			manifestContent:       baseManifest,
+			expectManifestContent: baseManifest,
+		},
+		{
+			name:                  "when lower bitrate bound is larger than upper bound, expect nothing is stripped from manifest",
+			filters:               &parsers.MediaFilters{MinBitrate: 10000, MaxBitrate: 100},
+			manifestContent:       baseManifest,
+			expectManifestContent: baseManifest,
+		},
+		{
+			name:                  "when hitting lower boundary (minBitrate = 0), expect results to be filtered",
+			filters:               &parsers.MediaFilters{MinBitrate: 0, MaxBitrate: 4000},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestFiltering4096Representation,
+		},
+		{
+			name:                  "when hitting upper bounary (maxBitrate = math.MaxInt32), expect results to be filtered",
+			filters:               &parsers.MediaFilters{MinBitrate: 4000, MaxBitrate: math.MaxInt32},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestFiltering256And2048Representations,
+		},
+		{
+			name:                  "when invalid minimum bitrate and valid maximum bitrate, expect nothing is filtered from the manifest",
+			filters:               &parsers.MediaFilters{MinBitrate: -1000, MaxBitrate: 1000},
+			manifestContent:       baseManifest,
+			expectManifestContent: baseManifest,
+		},
+		{
+			name:                  "when valid minimum bitrate and invalid maximum bitrate, expect nothing is filtered from manifest",
+			filters:               &parsers.MediaFilters{MinBitrate: 100, MaxBitrate: math.MaxInt32 + 1},
+			manifestContent:       baseManifest,
+			expectManifestContent: baseManifest,
+		},
+		{
+			name:                  "when valid input, expect filtered results with no adaptation sets removed",
+			filters:               &parsers.MediaFilters{MinBitrate: 10, MaxBitrate: 4000},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestFiltering4096Representation,
+		},
+		{
+			name:                  "when valid input, expect filtered results with one adaptation set removed",
+			filters:               &parsers.MediaFilters{MinBitrate: 100, MaxBitrate: 1000},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestFiltering2048And4096Representations,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_contentBitrate(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="400000" codecs="avc" id="0"></Representation>
+      <Representation bandwidth="2048000" codecs="avc" id="1"></Representation>
+      <Representation bandwidth="8192000" codecs="avc" id="2"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="96000" codecs="mp4a.40.2" id="0"></Representation>
+      <Representation bandwidth="384000" codecs="ec-3" id="1"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestFilteringVideo := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2048000" codecs="avc" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="96000" codecs="mp4a.40.2" id="0"></Representation>
+      <Representation bandwidth="384000" codecs="ec-3" id="1"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestFilteringVideoAndAudio := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2048000" codecs="avc" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="96000" codecs="mp4a.40.2" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestFilteringAllBitrates := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2048000" codecs="avc" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="384000" codecs="ec-3" id="1"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when a video bitrate range is given, expect audio representations to be kept",
+			filters:               &parsers.MediaFilters{VideoBitrate: &parsers.BitrateRange{Min: 500000, Max: 5000000}, MaxBitrate: math.MaxInt32},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestFilteringVideo,
+		},
+		{
+			name: "when video and audio bitrate ranges are given, expect each to apply to the adaptation " +
+				"sets of its content type",
+			filters: &parsers.MediaFilters{
+				VideoBitrate: &parsers.BitrateRange{Min: 500000, Max: 5000000},
+				AudioBitrate: &parsers.BitrateRange{Min: 64000, Max: 192000},
+				MaxBitrate:   math.MaxInt32,
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestFilteringVideoAndAudio,
+		},
+		{
+			name: "when a video bitrate range is given along with a bitrate range, expect both to be " +
+				"applied",
+			filters: &parsers.MediaFilters{
+				VideoBitrate: &parsers.BitrateRange{Min: 500000, Max: 5000000},
+				MinBitrate:   200000,
+				MaxBitrate:   math.MaxInt32,
+			},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestFilteringAllBitrates,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_resolution(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="1024" codecs="avc1.64001e" height="480" id="0" width="854"></Representation>
+      <Representation bandwidth="4096" codecs="avc1.640028" height="1080" id="1" width="1920"></Representation>
+      <Representation bandwidth="12288" codecs="avc1.640033" height="2160" id="2" width="3840"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" maxHeight="2160" contentType="video">
+      <Representation bandwidth="10240" codecs="hvc1.2.4.L153.B0" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="ac-3" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestUpTo1080p := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="1024" codecs="avc1.64001e" height="480" id="0" width="854"></Representation>
+      <Representation bandwidth="4096" codecs="avc1.640028" height="1080" id="1" width="1920"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="ac-3" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestFrom1080p := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="4096" codecs="avc1.640028" height="1080" id="1" width="1920"></Representation>
+      <Representation bandwidth="12288" codecs="avc1.640033" height="2160" id="2" width="3840"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" maxHeight="2160" contentType="video">
+      <Representation bandwidth="10240" codecs="hvc1.2.4.L153.B0" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="ac-3" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when no resolution filter is given, nothing is stripped from manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       baseManifest,
+			expectManifestContent: baseManifest,
+		},
+		{
+			name: "when a maximum height is given, expect representations above it and adaptation sets " +
+				"whose maxHeight is above it to be stripped out",
+			filters:               &parsers.MediaFilters{MaxHeight: 1080},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestUpTo1080p,
+		},
+		{
+			name:                  "when a minimum height is given, expect representations below it to be stripped out",
+			filters:               &parsers.MediaFilters{MinHeight: 1080},
+			manifestContent:       baseManifest,
+			expectManifestContent: manifestFrom1080p,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_frameRate(t *testing.T) {
+	baseManifest := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2048" codecs="avc1.64001f" frameRate="30000/1001" id="0"></Representation>
+      <Representation bandwidth="4096" codecs="avc1.640028" frameRate="60000/1001" id="1"></Representation>
+    </AdaptationSet>
+    <AdaptationSet frameRate="50" id="1" lang="en" contentType="video">
+      <Representation bandwidth="8192" codecs="hvc1.2.4.L123.B0" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="ac-3" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestUpTo30fps := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2048" codecs="avc1.64001f" frameRate="30000/1001" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="ac-3" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestUpTo50fps := `<?xml version="1.0" encoding="UTF-8"?>
 <MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
   <BaseURL>http://existing.base/url/</BaseURL>
   <Period>
-    <AdaptationSet id="0" lang="en" contentType="audio">
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2048" codecs="avc1.64001f" frameRate="30000/1001" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet frameRate="50" id="1" lang="en" contentType="video">
+      <Representation bandwidth="8192" codecs="hvc1.2.4.L123.B0" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="audio">
       <Representation bandwidth="256" codecs="ac-3" id="0"></Representation>
     </AdaptationSet>
   </Period>
 </MPD>
+`
+
+	manifestWithInvalidFrameRate := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="2048" codecs="avc1.64001f" frameRate="30000/0" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
 `
 
 	tests := []struct {
@@ -717,64 +2762,217 @@ func TestDASHFilter_FilterManifest_bitrate(t *testing.T) {
 		expectErr             bool
 	}{
 		{
-			name:                  "when no filters are given, nothing is stripped from manifest",
-			filters:               &parsers.MediaFilters{MinBitrate: 0, MaxBitrate: math.MaxInt32},
+			name:                  "when no frame rate filter is given, nothing is stripped from manifest",
+			filters:               &parsers.MediaFilters{},
 			manifestContent:       baseManifest,
 			expectManifestContent: baseManifest,
 		},
 		{
-			name:                  "when negative bitrates are entered, nothing is stripped from manifest",
-			filters:               &parsers.MediaFilters{MinBitrate: -100, MaxBitrate: -10},
+			name: "when filtering above 30fps, expect fractional frame rates above it and adaptation " +
+				"sets above it to be stripped out",
+			filters:               &parsers.MediaFilters{MaxFrameRate: 30},
 			manifestContent:       baseManifest,
-			expectManifestContent: baseManifest,
+			expectManifestContent: manifestUpTo30fps,
 		},
 		{
-			name:                  "when both bounds are exceeeded, expect nothing is stripped from manifest",
-			filters:               &parsers.MediaFilters{MinBitrate: -100, MaxBitrate: math.MaxInt32 + 1},
+			name:                  "when filtering above 50fps, expect representations above it to be stripped out",
+			filters:               &parsers.MediaFilters{MaxFrameRate: 50},
 			manifestContent:       baseManifest,
-			expectManifestContent: baseManifest,
+			expectManifestContent: manifestUpTo50fps,
 		},
 		{
-			name:                  "when lower bitrate bound is larger than upper bound, expect nothing is stripped from manifest",
-			filters:               &parsers.MediaFilters{MinBitrate: 10000, MaxBitrate: 100},
-			manifestContent:       baseManifest,
-			expectManifestContent: baseManifest,
+			name:                  "when a frame rate can't be parsed, expect the representation to be kept",
+			filters:               &parsers.MediaFilters{MaxFrameRate: 30},
+			manifestContent:       manifestWithInvalidFrameRate,
+			expectManifestContent: manifestWithInvalidFrameRate,
 		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_audioLanguages(t *testing.T) {
+	manifestWithMultiAudioLanguages := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="256" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="mp4a.40.2" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="es-MX" contentType="audio">
+      <Representation bandwidth="256" codecs="mp4a.40.2" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="es-MX" contentType="text">
+      <Representation bandwidth="256" codecs="wvtt" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutSpanishAudio := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="256" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="en" contentType="audio">
+      <Representation bandwidth="256" codecs="mp4a.40.2" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="es-MX" contentType="text">
+      <Representation bandwidth="256" codecs="wvtt" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
 		{
-			name:                  "when hitting lower boundary (minBitrate = 0), expect results to be filtered",
-			filters:               &parsers.MediaFilters{MinBitrate: 0, MaxBitrate: 4000},
-			manifestContent:       baseManifest,
-			expectManifestContent: manifestFiltering4096Representation,
+			name:                  "when no audio language filters are supplied, nothing is stripped from manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithMultiAudioLanguages,
+			expectManifestContent: manifestWithMultiAudioLanguages,
 		},
 		{
-			name:                  "when hitting upper bounary (maxBitrate = math.MaxInt32), expect results to be filtered",
-			filters:               &parsers.MediaFilters{MinBitrate: 4000, MaxBitrate: math.MaxInt32},
-			manifestContent:       baseManifest,
-			expectManifestContent: manifestFiltering256And2048Representations,
+			name:                  "when an audio language filter is supplied with es-MX, spanish audio is stripped out",
+			filters:               &parsers.MediaFilters{AudioLanguages: []parsers.AudioLanguage{"es-MX"}},
+			manifestContent:       manifestWithMultiAudioLanguages,
+			expectManifestContent: manifestWithoutSpanishAudio,
 		},
 		{
-			name:                  "when invalid minimum bitrate and valid maximum bitrate, expect nothing is filtered from the manifest",
-			filters:               &parsers.MediaFilters{MinBitrate: -1000, MaxBitrate: 1000},
-			manifestContent:       baseManifest,
-			expectManifestContent: baseManifest,
+			name:            "when an audio language filter removes every audio track, an error is returned",
+			filters:         &parsers.MediaFilters{AudioLanguages: []parsers.AudioLanguage{"en", "es-MX"}},
+			manifestContent: manifestWithMultiAudioLanguages,
+			expectErr:       true,
 		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewDASHFilter("", tt.manifestContent, config.Config{})
+
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestDASHFilter_FilterManifest_captionLanguages(t *testing.T) {
+	manifestWithMultiCaptionLanguages := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="256" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="es-MX" contentType="audio">
+      <Representation bandwidth="256" codecs="mp4a.40.2" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="text">
+      <Representation bandwidth="256" codecs="wvtt" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="3" lang="es-MX" contentType="text">
+      <Representation bandwidth="256" codecs="wvtt" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutSpanishCaptions := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="256" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="es-MX" contentType="audio">
+      <Representation bandwidth="256" codecs="mp4a.40.2" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="2" lang="en" contentType="text">
+      <Representation bandwidth="256" codecs="wvtt" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	manifestWithoutCaptions := `<?xml version="1.0" encoding="UTF-8"?>
+<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" type="static" mediaPresentationDuration="PT6M16S" minBufferTime="PT1.97S">
+  <BaseURL>http://existing.base/url/</BaseURL>
+  <Period>
+    <AdaptationSet id="0" lang="en" contentType="video">
+      <Representation bandwidth="256" codecs="avc1.640028" id="0"></Representation>
+    </AdaptationSet>
+    <AdaptationSet id="1" lang="es-MX" contentType="audio">
+      <Representation bandwidth="256" codecs="mp4a.40.2" id="0"></Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
 		{
-			name:                  "when valid minimum bitrate and invalid maximum bitrate, expect nothing is filtered from manifest",
-			filters:               &parsers.MediaFilters{MinBitrate: 100, MaxBitrate: math.MaxInt32 + 1},
-			manifestContent:       baseManifest,
-			expectManifestContent: baseManifest,
+			name:                  "when no caption language filters are supplied, nothing is stripped from manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithMultiCaptionLanguages,
+			expectManifestContent: manifestWithMultiCaptionLanguages,
 		},
 		{
-			name:                  "when valid input, expect filtered results with no adaptation sets removed",
-			filters:               &parsers.MediaFilters{MinBitrate: 10, MaxBitrate: 4000},
-			manifestContent:       baseManifest,
-			expectManifestContent: manifestFiltering4096Representation,
+			name: "when a caption language filter is supplied with es-MX, spanish captions are stripped " +
+				"out and spanish audio is kept",
+			filters:               &parsers.MediaFilters{CaptionLanguages: []parsers.CaptionLanguage{"es-MX"}},
+			manifestContent:       manifestWithMultiCaptionLanguages,
+			expectManifestContent: manifestWithoutSpanishCaptions,
 		},
 		{
-			name:                  "when valid input, expect filtered results with one adaptation set removed",
-			filters:               &parsers.MediaFilters{MinBitrate: 100, MaxBitrate: 1000},
-			manifestContent:       baseManifest,
-			expectManifestContent: manifestFiltering2048And4096Representations,
+			name:                  "when every caption language is supplied, captions are stripped from the manifest",
+			filters:               &parsers.MediaFilters{CaptionLanguages: []parsers.CaptionLanguage{"en", "es-MX"}},
+			manifestContent:       manifestWithMultiCaptionLanguages,
+			expectManifestContent: manifestWithoutCaptions,
 		},
 	}
 
@@ -785,7 +2983,7 @@ func TestDASHFilter_FilterManifest_bitrate(t *testing.T) {
 
 			manifest, err := filter.FilterManifest(tt.filters)
 			if err != nil && !tt.expectErr {
-				t.Errorf("FilterManifest() didn't expect error to be returned, got: %v", err)
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
 				return
 			} else if err == nil && tt.expectErr {
 				t.Error("FilterManifest() expected an error, got nil")
@@ -793,7 +2991,8 @@ func TestDASHFilter_FilterManifest_bitrate(t *testing.T) {
 			}
 
 			if g, e := manifest, tt.expectManifestContent; g != e {
-				t.Errorf("FilterManifest() returned wrong manifest\ngot %v\nexpected %v\ndiff: %v", g, e, cmp.Diff(g, e))
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
 			}
 		})
 	}
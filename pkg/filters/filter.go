@@ -1,8 +1,12 @@
 package filters
 
 import (
-	"github.com/cbsinteractive/bakery/pkg/parsers"
+	"net/url"
 	"strings"
+
+	"github.com/cbsinteractive/bakery/pkg/codecs"
+	"github.com/cbsinteractive/bakery/pkg/config"
+	"github.com/cbsinteractive/bakery/pkg/parsers"
 )
 
 // Filter is an interface for HLS and DASH filters
@@ -26,14 +30,173 @@ const (
 	hevcCodec  CodecFilterID = "hvc"
 	avcCodec   CodecFilterID = "avc"
 	dolbyCodec CodecFilterID = "dvh"
+	av1Codec   CodecFilterID = "av1"
+	vp9Codec   CodecFilterID = "vp9"
 	aacCodec   CodecFilterID = "mp4a"
 	ec3Codec   CodecFilterID = "ec-3"
 	ac3Codec   CodecFilterID = "ac-3"
+	ac4Codec   CodecFilterID = "ac-4"
+	opusCodec  CodecFilterID = "opus"
+	flacCodec  CodecFilterID = "flac"
 	stppCodec  CodecFilterID = "stpp"
 	wvttCodec  CodecFilterID = "wvtt"
 )
 
-// ValidCodecs returns a map of all formatted values for a given codec filter
+// ValidCodecs returns true if the codec string belongs to the codec family given by the filter,
+// or shares the parts of the filter when it is a codec string itself (e.g. hvc1.2)
 func ValidCodecs(codec string, filter CodecFilterID) bool {
-	return strings.Contains(codec, string(filter))
+	return codecs.Matches(codec, string(filter))
+}
+
+// exceedsCodecCaps returns true if the profile or level of a codec is not allowed by the
+// video profile and level caps. Codecs that don't declare a profile or level are allowed
+func exceedsCodecCaps(filters *parsers.MediaFilters, codec string) bool {
+	c := codecs.Parse(codec)
+	if maxLevel, found := filters.VideoLevels[c.Family]; found && c.Level > maxLevel {
+		return true
+	}
+
+	if profiles, found := filters.VideoProfiles[c.Family]; found && c.Profile != "" {
+		for _, profile := range profiles {
+			if profile == c.Profile {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// dynamicRanges returns the dynamic ranges a video stream can be played back in, given its
+// codec and the range signaled for it, which is empty when the manifest doesn't signal one.
+// Dolby Vision streams can also be played back in the range of their base layer, except for
+// profile 5 that has no cross compatible base layer
+func dynamicRanges(videoCodec string, signaled parsers.DynamicRange) []parsers.DynamicRange {
+	c := codecs.Parse(videoCodec)
+	if c.Family != codecs.FamilyDolbyVision {
+		if signaled == "" {
+			return []parsers.DynamicRange{parsers.DynamicRangeSDR}
+		}
+
+		return []parsers.DynamicRange{signaled}
+	}
+
+	ranges := []parsers.DynamicRange{parsers.DynamicRangeDolbyVision}
+	if c.Profile != "5" && signaled != "" {
+		ranges = append(ranges, signaled)
+	}
+
+	return ranges
+}
+
+// allowsDynamicRanges returns true if a stream that can be played back in any of the given
+// dynamic ranges is allowed by the dynamic range filter
+func allowsDynamicRanges(filters *parsers.MediaFilters, ranges []parsers.DynamicRange) bool {
+	for _, r := range ranges {
+		listed := false
+		for _, filtered := range filters.DynamicRanges {
+			if filtered == r {
+				listed = true
+				break
+			}
+		}
+
+		if listed == (filters.DynamicRangeMode == parsers.FilterModeInclude) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rejectsAudioChannels returns true if an audio stream with the given number of channels is
+// not allowed by the audio channels filter, or if it uses joint object coding (Dolby Atmos)
+// while those are excluded. A channel count of 0 stands for an unknown count, which is allowed
+func rejectsAudioChannels(filters *parsers.MediaFilters, channels int, joc bool) bool {
+	if filters.ExcludeJOC && joc {
+		return true
+	}
+
+	return filters.MaxAudioChannels > 0 && channels > filters.MaxAudioChannels
+}
+
+// keepsClosedCaptions returns true if a closed captions channel is allowed by the closed captions
+// filter, matching filter values against both its INSTREAM-ID and its language
+func keepsClosedCaptions(filters *parsers.MediaFilters, instreamID, language string) bool {
+	matched := false
+	for _, cc := range filters.ClosedCaptions {
+		if (instreamID != "" && strings.EqualFold(string(cc), instreamID)) ||
+			(language != "" && strings.EqualFold(string(cc), language)) {
+			matched = true
+			break
+		}
+	}
+
+	return matched == (filters.ClosedCaptionMode == parsers.FilterModeInclude)
+}
+
+// drmSystem identifies a DRM system by its DASH system ID and its HLS KEYFORMAT
+type drmSystem struct {
+	systemID  string
+	keyFormat string
+}
+
+// drmSystems are the DRM systems known to the drm filter
+var drmSystems = map[parsers.DRMSystem]drmSystem{
+	parsers.DRMSystemWidevine: {
+		systemID:  "edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",
+		keyFormat: "urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",
+	},
+	parsers.DRMSystemPlayReady: {
+		systemID:  "9a04f079-9840-4286-ab92-e65be0885f95",
+		keyFormat: "com.microsoft.playready",
+	},
+	parsers.DRMSystemFairPlay: {
+		systemID:  "94ce86fb-07ff-4f43-adb8-93d2fa968ca2",
+		keyFormat: "com.apple.streamingkeydelivery",
+	},
+}
+
+// keepsDRMSystem returns true if the DRM system matched by match is one of the systems
+// listed by the drm filter
+func keepsDRMSystem(filters *parsers.MediaFilters, match func(drmSystem) bool) bool {
+	for _, name := range filters.DRMSystems {
+		if system, found := drmSystems[name]; found && match(system) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// drmSystemName returns the name of the DRM system matched by match, or fallback when the
+// system isn't known to the drm filter
+func drmSystemName(match func(drmSystem) bool, fallback string) string {
+	for name, system := range drmSystems {
+		if match(system) {
+			return string(name)
+		}
+	}
+
+	return fallback
+}
+
+// licenseURLTemplate returns the template of the license URLs configured for the origin host
+// of a manifest, if any
+func licenseURLTemplate(c config.Config, manifestURL string) (string, bool) {
+	u, err := url.Parse(manifestURL)
+	if err != nil {
+		return "", false
+	}
+
+	template, found := c.LicenseURLs[u.Host]
+	return template, found && template != ""
+}
+
+// licenseURL expands a license URL template, replacing {drm} with the name of the DRM system
+// and {uri} with the escaped URI the license URL replaces, which is empty when there is none
+func licenseURL(template, system, uri string) string {
+	return strings.NewReplacer("{drm}", system, "{uri}", url.QueryEscape(uri)).Replace(template)
 }
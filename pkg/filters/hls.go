@@ -2,10 +2,14 @@ package filters
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/cbsinteractive/bakery/pkg/codecs"
 	"github.com/cbsinteractive/bakery/pkg/config"
 	"github.com/cbsinteractive/bakery/pkg/parsers"
 	"github.com/grafov/m3u8"
@@ -19,6 +23,28 @@ type HLSFilter struct {
 	config          config.Config
 }
 
+// Rendition types of the EXT-X-MEDIA tag
+const (
+	audioRendition          = "AUDIO"
+	videoRendition          = "VIDEO"
+	subtitlesRendition      = "SUBTITLES"
+	closedCaptionsRendition = "CLOSED-CAPTIONS"
+)
+
+// describesVideoCharacteristic is the CHARACTERISTICS value of audio renditions describing
+// the video for the visually impaired
+const describesVideoCharacteristic = "public.accessibility.describes-video"
+
+// identityKeyFormat is the KEYFORMAT of keys delivered as they are, which is the default one
+const identityKeyFormat = "identity"
+
+// streamTypeRenditions maps each stream type to the EXT-X-MEDIA rendition types carrying it
+var streamTypeRenditions = map[ContentType][]string{
+	audioContentType:   {audioRendition},
+	videoContentType:   {videoRendition},
+	captionContentType: {subtitlesRendition, closedCaptionsRendition},
+}
+
 var matchFunctions = map[ContentType]func(string) bool{
 	audioContentType:   isAudioCodec,
 	videoContentType:   isVideoCodec,
@@ -37,25 +63,104 @@ func NewHLSFilter(manifestURL, manifestContent string, c config.Config) *HLSFilt
 // FilterManifest will be responsible for filtering the manifest
 // according  to the MediaFilters
 func (h *HLSFilter) FilterManifest(filters *parsers.MediaFilters) (string, error) {
-	m, manifestType, err := m3u8.DecodeFrom(strings.NewReader(h.manifestContent), true)
+	_, manifestType, err := m3u8.DecodeFrom(strings.NewReader(h.manifestContent), true)
 	if err != nil {
 		return "", err
 	}
 
-	if manifestType != m3u8.MASTER {
+	switch manifestType {
+	case m3u8.MEDIA:
+		return h.filterMediaPlaylist(filters)
+	case m3u8.MASTER:
+	default:
 		return "", errors.New("manifest type is wrong")
 	}
 
-	// convert into the master playlist type
-	manifest := m.(*m3u8.MasterPlaylist)
-	filteredManifest := m3u8.NewMasterPlaylist()
+	// decode into a master playlist that preserves every tag it doesn't filter
+	manifest := decodeMasterPlaylist(h.manifestContent)
 
-	for _, v := range manifest.Variants {
-		absoluteURL, _ := filepath.Split(h.manifestURL)
-		absolute, aErr := url.Parse(absoluteURL)
-		if aErr != nil {
-			return h.manifestContent, aErr
+	absoluteURL, _ := filepath.Split(h.manifestURL)
+	absolute, err := url.Parse(absoluteURL)
+	if err != nil {
+		return h.manifestContent, err
+	}
+
+	for _, a := range manifest.alternatives {
+		if a.URI, err = combinedIfRelative(a.URI, *absolute); err != nil {
+			return "", err
 		}
+	}
+
+	for _, tag := range manifest.tags {
+		if tag.name != "#EXT-X-SESSION-KEY" && tag.name != "#EXT-X-SESSION-DATA" {
+			continue
+		}
+
+		uri, found := tag.attributes.get("URI")
+		if !found || strings.HasPrefix(uri, "data:") {
+			continue
+		}
+
+		if uri, err = combinedIfRelative(uri, *absolute); err != nil {
+			return "", err
+		}
+		tag.setAttribute("URI", uri, true)
+	}
+
+	originalGroups := manifest.renditionGroups()
+
+	audioBitrates := audioGroupBitrates(manifest.variants)
+	emptiedAudioGroups, err := h.filterAudioBitrates(filters, manifest, audioBitrates)
+	if err != nil {
+		return "", err
+	}
+
+	languageEmptiedGroups, err := h.filterAudioLanguages(filters, manifest)
+	if err != nil {
+		return "", err
+	}
+	for group := range languageEmptiedGroups {
+		emptiedAudioGroups[group] = struct{}{}
+	}
+
+	channelsEmptiedGroups, err := h.filterAudioChannels(filters, manifest)
+	if err != nil {
+		return "", err
+	}
+	for group := range channelsEmptiedGroups {
+		emptiedAudioGroups[group] = struct{}{}
+	}
+
+	descriptionEmptiedGroups, err := h.filterAudioDescription(filters, manifest)
+	if err != nil {
+		return "", err
+	}
+	for group := range descriptionEmptiedGroups {
+		emptiedAudioGroups[group] = struct{}{}
+	}
+
+	h.filterCaptionLanguages(filters, manifest)
+	h.filterClosedCaptions(filters, manifest)
+	h.filterStreamTypeRenditions(filters, manifest)
+	h.filterSessionKeys(filters, manifest)
+	if err := h.rewriteKeyURIs(manifest.tags, *absolute); err != nil {
+		return "", err
+	}
+
+	if filtersStreamType(filters, videoContentType) {
+		addAudioOnlyVariants(manifest, audioBitrates)
+	}
+
+	remainingGroups := manifest.renditionGroups()
+
+	var filteredVariants, iframeVariants []*m3u8.Variant
+	for _, v := range manifest.variants {
+		if _, emptied := emptiedAudioGroups[v.Audio]; emptied && v.Audio != "" {
+			continue
+		}
+
+		dropEmptiedGroupReferences(v, originalGroups, remainingGroups)
+
 		normalizedVariant, err := h.normalizeVariant(v, *absolute)
 		if err != nil {
 			return "", err
@@ -65,24 +170,192 @@ func (h *HLSFilter) FilterManifest(filters *parsers.MediaFilters) (string, error
 			return "", err
 		}
 
-		if validatedFilters {
+		if validatedFilters || !h.validateVariantContentBitrates(filters, normalizedVariant, audioBitrates) {
+			continue
+		}
+
+		if normalizedVariant.Iframe {
+			iframeVariants = append(iframeVariants, normalizedVariant)
+			continue
+		}
+
+		filteredVariants = append(filteredVariants, normalizedVariant)
+	}
+	if len(filteredVariants) == 0 && len(manifest.variants) > 0 && filtersStreamType(filters, videoContentType) {
+		return "", &parsers.FilterError{
+			Key:    "fs",
+			Value:  string(videoContentType),
+			Reason: "the playlist has no audio that can be played without video",
+		}
+	}
+
+	manifest.variants = append(filteredVariants, filterIframeVariants(iframeVariants, manifest.variants, filteredVariants)...)
+	pruneOrphanedGroups(manifest)
+
+	if h.config.PublicURL != "" {
+		h.proxyPlaylistURIs(filters, manifest)
+	}
+
+	return manifest.String(), nil
+}
+
+// filterMediaPlaylist applies the segment level filters to a media playlist. Segment URIs and
+// the URIs of its tags are made absolute, as the playlist is no longer served by the origin
+func (h *HLSFilter) filterMediaPlaylist(filters *parsers.MediaFilters) (string, error) {
+	playlist := decodeMediaPlaylist(h.manifestContent)
+
+	absoluteURL, _ := filepath.Split(h.manifestURL)
+	absolute, err := url.Parse(absoluteURL)
+	if err != nil {
+		return h.manifestContent, err
+	}
+
+	for _, s := range playlist.segments {
+		if s.uri, err = combinedIfRelative(s.uri, *absolute); err != nil {
+			return "", err
+		}
+	}
+
+	for _, tag := range playlist.tags() {
+		uri, found := tag.attributes.get("URI")
+		if !found || strings.HasPrefix(uri, "data:") {
 			continue
 		}
 
-		filteredManifest.Append(normalizedVariant.URI, normalizedVariant.Chunklist, normalizedVariant.VariantParams)
+		if uri, err = combinedIfRelative(uri, *absolute); err != nil {
+			return "", err
+		}
+		tag.setAttribute("URI", uri, true)
+	}
+
+	if filters.Clip != nil {
+		if err := h.clipMediaPlaylist(filters.Clip, playlist); err != nil {
+			return "", err
+		}
+	}
+
+	if filters.DVRWindow > 0 {
+		h.filterDVRWindow(filters.DVRWindow, playlist)
+	}
+
+	if err := h.rewriteKeyURIs(playlist.tags(), *absolute); err != nil {
+		return "", err
+	}
+
+	return playlist.String(), nil
+}
+
+// filterDVRWindow removes the oldest segments of a live playlist, keeping the most recent ones
+// lasting no longer than window, and at least the last one. EVENT playlists become sliding window
+// ones, as segments are removed from them. VOD playlists are left as they are
+func (h *HLSFilter) filterDVRWindow(window time.Duration, playlist *mediaPlaylist) {
+	if playlist.isVOD() || len(playlist.segments) == 0 {
+		return
+	}
+
+	last := len(playlist.segments) - 1
+	first := last
+	total := playlist.segments[last].duration()
+	for first > 0 && total+playlist.segments[first-1].duration() <= window.Seconds() {
+		first--
+		total += playlist.segments[first].duration()
+	}
+
+	if first == 0 {
+		return
+	}
+
+	playlist.trimSegments(first, last)
+	playlist.removeHeaderTag("#EXT-X-PLAYLIST-TYPE")
+}
+
+// clipMediaPlaylist keeps the segments of a VOD playlist covering any part of the clip only
+func (h *HLSFilter) clipMediaPlaylist(clip *parsers.TimeRange, playlist *mediaPlaylist) error {
+	if !playlist.isVOD() {
+		return errors.New("time clipping requires a VOD playlist")
+	}
+
+	start, end := clip.Start.Seconds(), clip.End.Seconds()
+	first, last := -1, -1
+	var position float64
+	for i, s := range playlist.segments {
+		segmentEnd := position + s.duration()
+		if position < end && segmentEnd > start {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+		position = segmentEnd
+	}
+
+	if first < 0 {
+		return fmt.Errorf("time clip from %v to %v is outside of the playlist", clip.Start, clip.End)
 	}
 
-	return filteredManifest.String(), nil
+	playlist.trimSegments(first, last)
+
+	return nil
+}
+
+// proxyPlaylistURIs points the URIs of the variants and renditions served by the origin back
+// through Bakery, behind the same filters as the master playlist, so every playlist of a
+// playback session is served by Bakery
+func (h *HLSFilter) proxyPlaylistURIs(filters *parsers.MediaFilters, manifest *masterPlaylist) {
+	for _, v := range manifest.variants {
+		v.URI = h.proxiedURI(filters, v.URI)
+	}
+
+	for _, a := range manifest.alternatives {
+		a.URI = h.proxiedURI(filters, a.URI)
+	}
+}
+
+// proxiedURI returns the Bakery URL of an absolute URI served by the origin. URIs served by
+// other hosts are returned as they are
+func (h *HLSFilter) proxiedURI(filters *parsers.MediaFilters, uri string) string {
+	origin := strings.TrimSuffix(h.config.OriginHost, "/")
+	if origin == "" || !strings.HasPrefix(uri, origin+"/") {
+		return uri
+	}
+
+	return strings.TrimSuffix(h.config.PublicURL, "/") + filters.FilterPath + "/" +
+		strings.TrimLeft(strings.TrimPrefix(uri, origin), "/")
 }
 
 // Returns true if specified variant passes all filters
 func (h *HLSFilter) validateVariants(filters *parsers.MediaFilters, v *m3u8.Variant) (bool, error) {
-	if filters.DefinesBitrateFilter() {
+	// the bandwidth of an I-frame variant only accounts for its I-frames, so it can't be
+	// compared with the bitrate filter. I-frame variants follow the variants they belong to
+	if filters.DefinesBitrateFilter() && !v.Iframe {
 		if !(h.validateBandwidthVariant(filters.MinBitrate, filters.MaxBitrate, v)) {
 			return true, nil
 		}
 	}
 
+	if filters.DefinesResolutionFilter() {
+		if !h.validateVariantResolution(filters, v) {
+			return true, nil
+		}
+	}
+
+	// variants without a FRAME-RATE are kept
+	if filters.DefinesFrameRateFilter() && v.FrameRate > filters.MaxFrameRate {
+		return true, nil
+	}
+
+	if filters.DefinesDynamicRangeFilter() {
+		if !h.validateVariantDynamicRange(filters, v) {
+			return true, nil
+		}
+	}
+
+	if filters.FilterStreamTypes != nil {
+		if h.validateVariantStreamTypes(filters.FilterStreamTypes, v) {
+			return true, nil
+		}
+	}
+
 	variantCodecs := strings.Split(v.Codecs, ",")
 
 	if filters.Audios != nil {
@@ -90,7 +363,7 @@ func (h *HLSFilter) validateVariants(filters *parsers.MediaFilters, v *m3u8.Vari
 		for _, at := range filters.Audios {
 			supportedAudioTypes[string(at)] = struct{}{}
 		}
-		res, err := validateVariantCodecs(audioContentType, variantCodecs, supportedAudioTypes, matchFunctions)
+		res, err := validateVariantCodecs(audioContentType, variantCodecs, supportedAudioTypes, filters.AudioMode, matchFunctions)
 		if res {
 			return true, err
 		}
@@ -101,7 +374,7 @@ func (h *HLSFilter) validateVariants(filters *parsers.MediaFilters, v *m3u8.Vari
 		for _, vt := range filters.Videos {
 			supportedVideoTypes[string(vt)] = struct{}{}
 		}
-		res, err := validateVariantCodecs(videoContentType, variantCodecs, supportedVideoTypes, matchFunctions)
+		res, err := validateVariantCodecs(videoContentType, variantCodecs, supportedVideoTypes, filters.VideoMode, matchFunctions)
 		if res {
 			return true, err
 		}
@@ -112,17 +385,47 @@ func (h *HLSFilter) validateVariants(filters *parsers.MediaFilters, v *m3u8.Vari
 		for _, ct := range filters.CaptionTypes {
 			supportedCaptionTypes[string(ct)] = struct{}{}
 		}
-		res, err := validateVariantCodecs(captionContentType, variantCodecs, supportedCaptionTypes, matchFunctions)
+		res, err := validateVariantCodecs(captionContentType, variantCodecs, supportedCaptionTypes, filters.CaptionTypeMode, matchFunctions)
 		if res {
 			return true, err
 		}
 	}
 
+	if filters.DefinesCodecCapsFilter() {
+		for _, codec := range variantCodecs {
+			if isVideoCodec(codec) && exceedsCodecCaps(filters, codec) {
+				return true, nil
+			}
+		}
+	}
+
 	return false, nil
 }
 
-// Returns true if the given variant (variantCodecs) should be allowed through the filter for supportedCodecs of filterType
-func validateVariantCodecs(filterType ContentType, variantCodecs []string, supportedCodecs map[string]struct{}, supportedFilterTypes map[ContentType]func(string) bool) (bool, error) {
+// Returns true if the variant can be played back in a dynamic range allowed by the filter, based on
+// its VIDEO-RANGE and video codec. Audio only variants are always valid
+func (h *HLSFilter) validateVariantDynamicRange(filters *parsers.MediaFilters, v *m3u8.Variant) bool {
+	if isAudioOnlyVariant(v) {
+		return true
+	}
+
+	var videoCodec string
+	for _, codec := range strings.Split(v.Codecs, ",") {
+		if isVideoCodec(strings.TrimSpace(codec)) {
+			videoCodec = strings.TrimSpace(codec)
+			break
+		}
+	}
+
+	signaled := parsers.DynamicRange(strings.ToLower(v.VideoRange))
+
+	return allowsDynamicRanges(filters, dynamicRanges(videoCodec, signaled))
+}
+
+// Returns true if the given variant (variantCodecs) should be removed by the filter for supportedCodecs of filterType.
+// In exclude mode a variant is removed when any of its codecs of filterType is in supportedCodecs, and in include
+// mode when any of them is not
+func validateVariantCodecs(filterType ContentType, variantCodecs []string, supportedCodecs map[string]struct{}, mode parsers.FilterMode, supportedFilterTypes map[ContentType]func(string) bool) (bool, error) {
 	var matchFilterType func(string) bool
 
 	matchFilterType, found := supportedFilterTypes[filterType]
@@ -131,19 +434,589 @@ func validateVariantCodecs(filterType ContentType, variantCodecs []string, suppo
 		return false, errors.New("filter type is unsupported")
 	}
 
-	variantFound := false
+	include := mode == parsers.FilterModeInclude
 	for _, codec := range variantCodecs {
 		if matchFilterType(codec) {
+			variantFound := false
 			for sc := range supportedCodecs {
 				if ValidCodecs(codec, CodecFilterID(sc)) {
 					variantFound = true
 					break
 				}
 			}
+
+			if variantFound != include {
+				return true, nil
+			}
 		}
 	}
 
-	return variantFound, nil
+	return false, nil
+}
+
+// filterIframeVariants returns the I-frame variants that belong to a variant left after
+// filtering. I-frame variants that can't be matched with any variant of the original
+// manifest are kept, as only the filters applied to them directly can tell
+func filterIframeVariants(iframes, variants, filteredVariants []*m3u8.Variant) []*m3u8.Variant {
+	var kept []*m3u8.Variant
+	for _, iframe := range iframes {
+		if !matchesAnyVariant(iframe, variants) || matchesAnyVariant(iframe, filteredVariants) {
+			kept = append(kept, iframe)
+		}
+	}
+
+	return kept
+}
+
+// matchesAnyVariant returns true if the I-frame variant has the resolution and the video
+// codec of any of the given variants, ignoring whatever either of them leaves undeclared
+func matchesAnyVariant(iframe *m3u8.Variant, variants []*m3u8.Variant) bool {
+	iframeCodecs := videoCodecFamilies(iframe.Codecs)
+	for _, v := range variants {
+		if v.Iframe {
+			continue
+		}
+
+		if iframe.Resolution != "" && v.Resolution != "" && iframe.Resolution != v.Resolution {
+			continue
+		}
+
+		families := videoCodecFamilies(v.Codecs)
+		if len(iframeCodecs) == 0 || len(families) == 0 {
+			return true
+		}
+
+		for family := range iframeCodecs {
+			if _, found := families[family]; found {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// videoCodecFamilies returns the family of every video codec in a CODECS attribute
+func videoCodecFamilies(variantCodecs string) map[codecs.Family]struct{} {
+	families := map[codecs.Family]struct{}{}
+	for _, codec := range strings.Split(variantCodecs, ",") {
+		codec = strings.TrimSpace(codec)
+		if isVideoCodec(codec) {
+			families[codecs.Parse(codec).Family] = struct{}{}
+		}
+	}
+
+	return families
+}
+
+// filterAudioBitrates removes the renditions of the audio groups whose bitrate is known to be
+// outside of the audio bitrate range, and returns those groups. It fails when no audio rendition
+// is left
+func (h *HLSFilter) filterAudioBitrates(filters *parsers.MediaFilters, manifest *masterPlaylist, audioBitrates map[string]int) (map[string]struct{}, error) {
+	filteredGroups := map[string]struct{}{}
+	if filters.AudioBitrate == nil {
+		return filteredGroups, nil
+	}
+
+	for group, bitrate := range audioBitrates {
+		if !filters.AudioBitrate.Contains(bitrate) {
+			filteredGroups[group] = struct{}{}
+		}
+	}
+
+	_, remaining := filterAlternatives(manifest, audioRendition, func(a *m3u8.Alternative) bool {
+		_, filtered := filteredGroups[a.GroupId]
+		return !filtered
+	})
+
+	if remaining == 0 && len(filteredGroups) > 0 {
+		return nil, errors.New("audio bitrate filter removes every audio track")
+	}
+
+	return filteredGroups, nil
+}
+
+// audioGroupBitrates works out the bitrate of the audio groups referenced by audio only
+// variants, as their BANDWIDTH only accounts for the audio rendition
+func audioGroupBitrates(variants []*m3u8.Variant) map[string]int {
+	bitrates := map[string]int{}
+	for _, v := range variants {
+		if v.Audio == "" || !isAudioOnlyVariant(v) {
+			continue
+		}
+
+		if bandwidth := int(v.Bandwidth); bandwidth > bitrates[v.Audio] {
+			bitrates[v.Audio] = bandwidth
+		}
+	}
+
+	return bitrates
+}
+
+// isAudioOnlyVariant returns true if every codec of the variant is an audio codec
+func isAudioOnlyVariant(v *m3u8.Variant) bool {
+	if v.Iframe || v.Codecs == "" {
+		return false
+	}
+
+	for _, codec := range strings.Split(v.Codecs, ",") {
+		if !isAudioCodec(strings.TrimSpace(codec)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterAudioLanguages removes the audio renditions in any of the languages given
+// by the audio language filter and returns the audio groups left without renditions
+func (h *HLSFilter) filterAudioLanguages(filters *parsers.MediaFilters, manifest *masterPlaylist) (map[string]struct{}, error) {
+	if len(filters.AudioLanguages) == 0 {
+		return nil, nil
+	}
+
+	filteredLanguages := map[string]struct{}{}
+	for _, al := range filters.AudioLanguages {
+		filteredLanguages[strings.ToLower(string(al))] = struct{}{}
+	}
+
+	emptiedGroups, remaining := filterAlternatives(manifest, audioRendition, func(a *m3u8.Alternative) bool {
+		_, filtered := filteredLanguages[strings.ToLower(a.Language)]
+		return !filtered
+	})
+
+	if remaining == 0 && len(emptiedGroups) > 0 {
+		return nil, fmt.Errorf("audio language filter %v removes every audio track", filters.AudioLanguages)
+	}
+
+	return emptiedGroups, nil
+}
+
+// filterAudioChannels removes the audio renditions whose CHANNELS attribute isn't allowed by
+// the audio channels filter and returns the audio groups left without renditions. Renditions
+// without a CHANNELS attribute are kept
+func (h *HLSFilter) filterAudioChannels(filters *parsers.MediaFilters, manifest *masterPlaylist) (map[string]struct{}, error) {
+	if !filters.DefinesAudioChannelsFilter() {
+		return nil, nil
+	}
+
+	emptiedGroups, remaining := filterAlternatives(manifest, audioRendition, func(a *m3u8.Alternative) bool {
+		channels, found := manifest.alternativeAttribute(a, "CHANNELS")
+		if !found {
+			return true
+		}
+
+		count, joc := parseChannels(channels)
+		return !rejectsAudioChannels(filters, count, joc)
+	})
+
+	if remaining == 0 && len(emptiedGroups) > 0 {
+		return nil, errors.New("audio channels filter removes every audio track")
+	}
+
+	return emptiedGroups, nil
+}
+
+// parseChannels reads the CHANNELS attribute of an audio rendition, e.g. 6 or 16/JOC, returning
+// the channel count and whether the audio uses joint object coding. The count is 0 when it
+// can't be parsed
+func parseChannels(channels string) (int, bool) {
+	params := strings.Split(channels, "/")
+	count, _ := strconv.Atoi(params[0])
+
+	joc := false
+	if len(params) > 1 {
+		for _, coding := range strings.Split(params[1], ",") {
+			if strings.EqualFold(coding, "JOC") {
+				joc = true
+			}
+		}
+	}
+
+	return count, joc
+}
+
+// filterAudioDescription removes the audio renditions describing the video for the visually
+// impaired, or every other audio rendition when only those are kept, and returns the audio groups
+// left without renditions
+func (h *HLSFilter) filterAudioDescription(filters *parsers.MediaFilters, manifest *masterPlaylist) (map[string]struct{}, error) {
+	if filters.AudioDescription == "" {
+		return nil, nil
+	}
+
+	only := filters.AudioDescription == parsers.AudioDescriptionOnly
+	emptiedGroups, remaining := filterAlternatives(manifest, audioRendition, func(a *m3u8.Alternative) bool {
+		return hasCharacteristic(a, describesVideoCharacteristic) == only
+	})
+
+	if remaining == 0 && len(emptiedGroups) > 0 {
+		return nil, fmt.Errorf("audio description filter %q removes every audio track", filters.AudioDescription)
+	}
+
+	return emptiedGroups, nil
+}
+
+// hasCharacteristic returns true if characteristic is one of the CHARACTERISTICS of a rendition
+func hasCharacteristic(a *m3u8.Alternative, characteristic string) bool {
+	for _, c := range strings.Split(a.Characteristics, ",") {
+		if strings.TrimSpace(c) == characteristic {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterCaptionLanguages removes the subtitle renditions in any of the languages given
+// by the caption language filter
+func (h *HLSFilter) filterCaptionLanguages(filters *parsers.MediaFilters, manifest *masterPlaylist) {
+	if len(filters.CaptionLanguages) == 0 {
+		return
+	}
+
+	filteredLanguages := map[string]struct{}{}
+	for _, cl := range filters.CaptionLanguages {
+		filteredLanguages[strings.ToLower(string(cl))] = struct{}{}
+	}
+
+	filterAlternatives(manifest, subtitlesRendition, func(a *m3u8.Alternative) bool {
+		_, filtered := filteredLanguages[strings.ToLower(a.Language)]
+		return !filtered
+	})
+}
+
+// filterClosedCaptions removes the closed captions renditions rejected by the closed captions
+// filter. Variants referring to a group left without renditions get their CLOSED-CAPTIONS set
+// to NONE
+func (h *HLSFilter) filterClosedCaptions(filters *parsers.MediaFilters, manifest *masterPlaylist) {
+	if len(filters.ClosedCaptions) == 0 {
+		return
+	}
+
+	filterAlternatives(manifest, closedCaptionsRendition, func(a *m3u8.Alternative) bool {
+		instreamID, _ := manifest.alternativeAttribute(a, "INSTREAM-ID")
+		return keepsClosedCaptions(filters, instreamID, a.Language)
+	})
+}
+
+// filterStreamTypeRenditions removes the EXT-X-MEDIA renditions of every stream type
+// given by the stream type filter
+func (h *HLSFilter) filterStreamTypeRenditions(filters *parsers.MediaFilters, manifest *masterPlaylist) {
+	for _, streamType := range filters.FilterStreamTypes {
+		for _, renditionType := range streamTypeRenditions[ContentType(streamType)] {
+			filterAlternatives(manifest, renditionType, func(*m3u8.Alternative) bool {
+				return false
+			})
+		}
+	}
+}
+
+// filterSessionKeys removes the EXT-X-SESSION-KEY tags of the DRM systems not listed by the
+// drm filter. Keys without a KEYFORMAT, or with the identity one, aren't tied to a DRM system
+// and are kept
+func (h *HLSFilter) filterSessionKeys(filters *parsers.MediaFilters, manifest *masterPlaylist) {
+	if len(filters.DRMSystems) == 0 {
+		return
+	}
+
+	manifest.filterTags("#EXT-X-SESSION-KEY", func(attributes attributeList) bool {
+		keyFormat, found := attributes.get("KEYFORMAT")
+		if !found || keyFormat == identityKeyFormat {
+			return true
+		}
+
+		return keepsDRMSystem(filters, func(system drmSystem) bool {
+			return strings.EqualFold(system.keyFormat, keyFormat)
+		})
+	})
+}
+
+// rewriteKeyURIs points the URIs of the EXT-X-KEY and EXT-X-SESSION-KEY tags to the license URL
+// configured for the origin of the playlist. Only keys fetched over HTTP are rewritten, as the URIs
+// of other schemes, such as data URIs or the skd URIs of FairPlay, identify the key themselves
+func (h *HLSFilter) rewriteKeyURIs(tags []*playlistTag, absolute url.URL) error {
+	template, found := licenseURLTemplate(h.config, h.manifestURL)
+	if !found {
+		return nil
+	}
+
+	for _, tag := range tags {
+		if tag.name != "#EXT-X-KEY" && tag.name != "#EXT-X-SESSION-KEY" {
+			continue
+		}
+
+		uri, found := tag.attributes.get("URI")
+		if !found {
+			continue
+		}
+
+		uri, err := combinedIfRelative(uri, absolute)
+		if err != nil {
+			return err
+		}
+
+		if !isHTTPURI(uri) {
+			continue
+		}
+
+		keyFormat, found := tag.attributes.get("KEYFORMAT")
+		if !found {
+			keyFormat = identityKeyFormat
+		}
+
+		system := drmSystemName(func(system drmSystem) bool {
+			return strings.EqualFold(system.keyFormat, keyFormat)
+		}, keyFormat)
+		tag.setAttribute("URI", licenseURL(template, system, uri), true)
+	}
+
+	return nil
+}
+
+// isHTTPURI returns true if uri uses the http or https scheme
+func isHTTPURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// Returns true if the height of the variant RESOLUTION is within the resolution filter range.
+// Variants without a RESOLUTION, such as audio only variants, are kept
+func (h *HLSFilter) validateVariantResolution(filters *parsers.MediaFilters, v *m3u8.Variant) bool {
+	i := strings.Index(strings.ToLower(v.Resolution), "x")
+	if i < 0 {
+		return true
+	}
+
+	height, err := strconv.Atoi(v.Resolution[i+1:])
+	if err != nil {
+		return true
+	}
+
+	return filters.ValidHeight(height)
+}
+
+// Returns true if the variant carries a filtered out video stream or nothing but filtered out
+// streams. Otherwise the codecs and rendition groups of the filtered stream types are stripped
+// from the variant
+func (h *HLSFilter) validateVariantStreamTypes(streamTypes []parsers.StreamType, v *m3u8.Variant) bool {
+	filteredTypes := map[ContentType]struct{}{}
+	for _, streamType := range streamTypes {
+		filteredTypes[ContentType(streamType)] = struct{}{}
+	}
+
+	if _, filtered := filteredTypes[videoContentType]; filtered {
+		if v.Iframe {
+			return true
+		}
+
+		v.Video = ""
+	}
+
+	if _, filtered := filteredTypes[audioContentType]; filtered {
+		v.Audio = ""
+	}
+
+	if _, filtered := filteredTypes[captionContentType]; filtered {
+		v.Subtitles = ""
+		v.Captions = ""
+	}
+
+	if v.Codecs == "" {
+		return false
+	}
+
+	var filteredCodecs []string
+	for _, codec := range strings.Split(v.Codecs, ",") {
+		codecFiltered := false
+		for ct := range filteredTypes {
+			if matchFilterType, found := matchFunctions[ct]; found && matchFilterType(codec) {
+				codecFiltered = true
+				break
+			}
+		}
+
+		if !codecFiltered {
+			filteredCodecs = append(filteredCodecs, codec)
+			continue
+		}
+
+		if isVideoCodec(codec) {
+			return true
+		}
+	}
+
+	if len(filteredCodecs) == 0 {
+		return true
+	}
+
+	v.Codecs = strings.Join(filteredCodecs, ",")
+
+	return false
+}
+
+// filtersStreamType returns true if the stream type filter removes streamType
+func filtersStreamType(filters *parsers.MediaFilters, streamType ContentType) bool {
+	for _, filtered := range filters.FilterStreamTypes {
+		if ContentType(filtered) == streamType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addAudioOnlyVariants adds an audio only variant for each audio group referred to by variants
+// when the playlist has none for it, so that removing video leaves a playable playlist. The
+// variant points to the default rendition of the group, and its BANDWIDTH is the one of the
+// lowest variant referring to the group, as master playlists don't declare the bitrate of renditions
+func addAudioOnlyVariants(manifest *masterPlaylist, audioBitrates map[string]int) {
+	var groups []string
+	lowest := map[string]*m3u8.Variant{}
+	for _, v := range manifest.variants {
+		if v.Iframe || v.Audio == "" {
+			continue
+		}
+
+		if _, found := audioBitrates[v.Audio]; found {
+			continue
+		}
+
+		current, found := lowest[v.Audio]
+		if !found {
+			groups = append(groups, v.Audio)
+		}
+		if !found || v.Bandwidth < current.Bandwidth {
+			lowest[v.Audio] = v
+		}
+	}
+
+	for _, group := range groups {
+		uri := audioGroupURI(manifest, group)
+		if uri == "" {
+			continue
+		}
+
+		v := lowest[group]
+		attributes := attributeList{{key: "BANDWIDTH", value: strconv.FormatUint(uint64(v.Bandwidth), 10)}}
+
+		var codecs []string
+		for _, codec := range strings.Split(v.Codecs, ",") {
+			if isAudioCodec(codec) {
+				codecs = append(codecs, codec)
+			}
+		}
+		if len(codecs) > 0 {
+			attributes = append(attributes, attribute{key: "CODECS", value: strings.Join(codecs, ","), quoted: true})
+		}
+
+		attributes = append(attributes, attribute{key: "AUDIO", value: group, quoted: true})
+		manifest.insertVariant(attributes, uri, v)
+	}
+}
+
+// audioGroupURI returns the URI of the default rendition of an audio group, or of its first
+// rendition with a URI when none is the default
+func audioGroupURI(manifest *masterPlaylist, group string) string {
+	uri := ""
+	for _, a := range manifest.alternatives {
+		if a.Type != audioRendition || a.GroupId != group || a.URI == "" {
+			continue
+		}
+
+		if a.Default {
+			return a.URI
+		}
+
+		if uri == "" {
+			uri = a.URI
+		}
+	}
+
+	return uri
+}
+
+// dropEmptiedGroupReferences removes the references of a variant to rendition groups
+// that were left without renditions by the filters
+func dropEmptiedGroupReferences(v *m3u8.Variant, originalGroups, remainingGroups map[renditionGroup]struct{}) {
+	emptied := func(renditionType, groupID string) bool {
+		group := renditionGroup{renditionType: renditionType, groupID: groupID}
+		_, existed := originalGroups[group]
+		_, remains := remainingGroups[group]
+		return existed && !remains
+	}
+
+	if emptied(audioRendition, v.Audio) {
+		v.Audio = ""
+	}
+	if emptied(videoRendition, v.Video) {
+		v.Video = ""
+	}
+	if emptied(subtitlesRendition, v.Subtitles) {
+		v.Subtitles = ""
+	}
+	// NONE tells players not to render closed captions carried in the video stream either
+	if emptied(closedCaptionsRendition, v.Captions) {
+		v.Captions = "NONE"
+	}
+}
+
+// pruneOrphanedGroups removes the renditions of every group no remaining variant refers to
+func pruneOrphanedGroups(manifest *masterPlaylist) {
+	referenced := map[renditionGroup]struct{}{}
+	for _, v := range manifest.variants {
+		referenced[renditionGroup{renditionType: audioRendition, groupID: v.Audio}] = struct{}{}
+		referenced[renditionGroup{renditionType: videoRendition, groupID: v.Video}] = struct{}{}
+		referenced[renditionGroup{renditionType: subtitlesRendition, groupID: v.Subtitles}] = struct{}{}
+		referenced[renditionGroup{renditionType: closedCaptionsRendition, groupID: v.Captions}] = struct{}{}
+	}
+
+	var alternatives []*m3u8.Alternative
+	for _, a := range manifest.alternatives {
+		if _, found := referenced[renditionGroup{renditionType: a.Type, groupID: a.GroupId}]; found {
+			alternatives = append(alternatives, a)
+		}
+	}
+	manifest.alternatives = alternatives
+}
+
+// filterAlternatives removes the EXT-X-MEDIA renditions of renditionType rejected by keep.
+// It returns the groups that were left without renditions and the number of renditions
+// of that type still in the manifest
+func filterAlternatives(manifest *masterPlaylist, renditionType string, keep func(*m3u8.Alternative) bool) (map[string]struct{}, int) {
+	renditionsPerGroup := map[string]int{}
+	remaining := 0
+
+	var filteredAlternatives []*m3u8.Alternative
+	for _, a := range manifest.alternatives {
+		if a.Type == renditionType {
+			if _, found := renditionsPerGroup[a.GroupId]; !found {
+				renditionsPerGroup[a.GroupId] = 0
+			}
+
+			if !keep(a) {
+				continue
+			}
+
+			renditionsPerGroup[a.GroupId]++
+			remaining++
+		}
+
+		filteredAlternatives = append(filteredAlternatives, a)
+	}
+	manifest.alternatives = filteredAlternatives
+
+	emptiedGroups := map[string]struct{}{}
+	for group, renditions := range renditionsPerGroup {
+		if renditions == 0 {
+			emptiedGroups[group] = struct{}{}
+		}
+	}
+
+	return emptiedGroups, remaining
 }
 
 func (h *HLSFilter) validateBandwidthVariant(minBitrate int, maxBitrate int, v *m3u8.Variant) bool {
@@ -155,15 +1028,30 @@ func (h *HLSFilter) validateBandwidthVariant(minBitrate int, maxBitrate int, v *
 	return true
 }
 
-func (h *HLSFilter) normalizeVariant(v *m3u8.Variant, absolute url.URL) (*m3u8.Variant, error) {
-	for _, a := range v.VariantParams.Alternatives {
-		aURL, aErr := combinedIfRelative(a.URI, absolute)
-		if aErr != nil {
-			return v, aErr
-		}
-		a.URI = aURL
+// Returns true if the variant is within the bitrate range of its content type. The video
+// bitrate of a variant is its BANDWIDTH minus the bitrate of its audio group, when known
+func (h *HLSFilter) validateVariantContentBitrates(filters *parsers.MediaFilters, v *m3u8.Variant, audioBitrates map[string]int) bool {
+	if !filters.DefinesContentBitrateFilter() || v.Iframe {
+		return true
+	}
+
+	bandwidth := int(v.Bandwidth)
+	if isAudioOnlyVariant(v) {
+		return filters.AudioBitrate == nil || filters.AudioBitrate.Contains(bandwidth)
+	}
+
+	if filters.VideoBitrate == nil || (len(videoCodecFamilies(v.Codecs)) == 0 && v.Resolution == "") {
+		return true
+	}
+
+	if audioBitrate, found := audioBitrates[v.Audio]; found && audioBitrate < bandwidth {
+		bandwidth -= audioBitrate
 	}
 
+	return filters.VideoBitrate.Contains(bandwidth)
+}
+
+func (h *HLSFilter) normalizeVariant(v *m3u8.Variant, absolute url.URL) (*m3u8.Variant, error) {
 	vURL, vErr := combinedIfRelative(v.URI, absolute)
 	if vErr != nil {
 		return v, vErr
@@ -198,18 +1086,23 @@ func isRelative(urlStr string) (bool, error) {
 	return !u.IsAbs(), nil
 }
 
-// Returns true if given codec is an audio codec (mp4a, ec-3, or ac-3)
+// Returns true if given codec is an audio codec (mp4a, ec-3, ac-3, ac-4, opus or flac)
 func isAudioCodec(codec string) bool {
 	return (ValidCodecs(codec, aacCodec) ||
 		ValidCodecs(codec, ec3Codec) ||
-		ValidCodecs(codec, ac3Codec))
+		ValidCodecs(codec, ac3Codec) ||
+		ValidCodecs(codec, ac4Codec) ||
+		ValidCodecs(codec, opusCodec) ||
+		ValidCodecs(codec, flacCodec))
 }
 
-// Returns true if given codec is a video codec (hvc, avc, or dvh)
+// Returns true if given codec is a video codec (hvc, avc, dvh, av1 or vp9)
 func isVideoCodec(codec string) bool {
 	return (ValidCodecs(codec, hevcCodec) ||
 		ValidCodecs(codec, avcCodec) ||
-		ValidCodecs(codec, dolbyCodec))
+		ValidCodecs(codec, dolbyCodec) ||
+		ValidCodecs(codec, av1Codec) ||
+		ValidCodecs(codec, vp9Codec))
 }
 
 // Returns true if goven codec is a caption codec (stpp or wvtt)
@@ -2,11 +2,15 @@ package filters
 
 import (
 	"math"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/cbsinteractive/bakery/pkg/codecs"
 	"github.com/cbsinteractive/bakery/pkg/config"
 	"github.com/cbsinteractive/bakery/pkg/parsers"
 	"github.com/google/go-cmp/cmp"
+	"github.com/grafov/m3u8"
 )
 
 func TestHLSFilter_FilterManifest_BandwidthFilter(t *testing.T) {
@@ -28,7 +32,8 @@ http://existing.base/uri/link_1.m3u8
 `
 
 	manifestRemovedHigherBW := `#EXTM3U
-#EXT-X-VERSION:3
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="CC",NAME="ENGLISH",DEFAULT=NO,LANGUAGE="ENG"
 #EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CLOSED-CAPTIONS="CC"
 http://existing.base/uri/link_2.m3u8
 `
@@ -475,6 +480,316 @@ http://existing.base/uri/link_9.m3u8
 	}
 }
 
+func TestHLSFilter_FilterManifest_HEVCSampleEntries(t *testing.T) {
+	manifestWithHEVCSampleEntries := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.640020"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="hvc1.2.4.L123.B0"
+http://existing.base/uri/link_2.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="hev1.2.4.L123.B0"
+http://existing.base/uri/link_3.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="hev1.1.6.L120.90"
+http://existing.base/uri/link_4.m3u8
+`
+
+	manifestWithoutHEVC := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.640020"
+http://existing.base/uri/link_1.m3u8
+`
+
+	manifestWithoutHDR10 := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.640020"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="hev1.1.6.L120.90"
+http://existing.base/uri/link_4.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when filtering hvc, expect both hvc1 and hev1 variants to be stripped out",
+			filters:               &parsers.MediaFilters{Videos: []parsers.VideoType{"hvc"}},
+			manifestContent:       manifestWithHEVCSampleEntries,
+			expectManifestContent: manifestWithoutHEVC,
+		},
+		{
+			name:                  "when filtering hevc, expect both hvc1 and hev1 variants to be stripped out",
+			filters:               &parsers.MediaFilters{Videos: []parsers.VideoType{"hevc"}},
+			manifestContent:       manifestWithHEVCSampleEntries,
+			expectManifestContent: manifestWithoutHEVC,
+		},
+		{
+			name: "when filtering hdr10, expect main10 variants to be stripped out regardless of their " +
+				"sample entry",
+			filters:               &parsers.MediaFilters{Videos: []parsers.VideoType{"hev1.2", "hvc1.2"}},
+			manifestContent:       manifestWithHEVCSampleEntries,
+			expectManifestContent: manifestWithoutHDR10,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_AV1Ladder(t *testing.T) {
+	manifestWithAV1 := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="opus",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/opus_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,AUDIO="aac"
+http://existing.base/uri/avc_720p.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/avc_1080p.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1200000,AVERAGE-BANDWIDTH=1200000,CODECS="av01.0.05M.08,Opus",RESOLUTION=1280x720,AUDIO="opus"
+http://existing.base/uri/av1_720p.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000000,AVERAGE-BANDWIDTH=3000000,CODECS="av01.0.08M.08,Opus",RESOLUTION=1920x1080,AUDIO="opus"
+http://existing.base/uri/av1_1080p.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=9000000,AVERAGE-BANDWIDTH=9000000,CODECS="av01.0.12M.10,Opus",RESOLUTION=3840x2160,AUDIO="opus"
+http://existing.base/uri/av1_2160p.m3u8
+`
+
+	manifestWithoutAV1 := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,AUDIO="aac"
+http://existing.base/uri/avc_720p.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/avc_1080p.m3u8
+`
+
+	manifestWithAV1Only := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="opus",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/opus_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1200000,AVERAGE-BANDWIDTH=1200000,CODECS="av01.0.05M.08,Opus",RESOLUTION=1280x720,AUDIO="opus"
+http://existing.base/uri/av1_720p.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000000,AVERAGE-BANDWIDTH=3000000,CODECS="av01.0.08M.08,Opus",RESOLUTION=1920x1080,AUDIO="opus"
+http://existing.base/uri/av1_1080p.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=9000000,AVERAGE-BANDWIDTH=9000000,CODECS="av01.0.12M.10,Opus",RESOLUTION=3840x2160,AUDIO="opus"
+http://existing.base/uri/av1_2160p.m3u8
+`
+
+	manifestWithoutAV1Level5 := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="opus",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/opus_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,AUDIO="aac"
+http://existing.base/uri/avc_720p.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/avc_1080p.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1200000,AVERAGE-BANDWIDTH=1200000,CODECS="av01.0.05M.08,Opus",RESOLUTION=1280x720,AUDIO="opus"
+http://existing.base/uri/av1_720p.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000000,AVERAGE-BANDWIDTH=3000000,CODECS="av01.0.08M.08,Opus",RESOLUTION=1920x1080,AUDIO="opus"
+http://existing.base/uri/av1_1080p.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when filtering av1, expect av1 variants and their audio group to be stripped out",
+			filters:               &parsers.MediaFilters{Videos: []parsers.VideoType{"av1"}},
+			manifestContent:       manifestWithAV1,
+			expectManifestContent: manifestWithoutAV1,
+		},
+		{
+			name:                  "when filtering opus, expect opus variants and their audio group to be stripped out",
+			filters:               &parsers.MediaFilters{Audios: []parsers.AudioType{"opus"}},
+			manifestContent:       manifestWithAV1,
+			expectManifestContent: manifestWithoutAV1,
+		},
+		{
+			name: "when only av1 is included, expect avc variants and their audio group to be " +
+				"stripped out",
+			filters: &parsers.MediaFilters{
+				Videos:    []parsers.VideoType{"av1"},
+				VideoMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       manifestWithAV1,
+			expectManifestContent: manifestWithAV1Only,
+		},
+		{
+			name: "when only opus is included, expect aac variants and their audio group to be " +
+				"stripped out",
+			filters: &parsers.MediaFilters{
+				Audios:    []parsers.AudioType{"opus"},
+				AudioMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       manifestWithAV1,
+			expectManifestContent: manifestWithAV1Only,
+		},
+		{
+			name:                  "when capping the av1 level, expect av1 variants above it to be stripped out",
+			filters:               &parsers.MediaFilters{VideoLevels: map[codecs.Family]float64{codecs.FamilyAV1: 4.1}},
+			manifestContent:       manifestWithAV1,
+			expectManifestContent: manifestWithoutAV1Level5,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_CodecCapsFilter(t *testing.T) {
+	manifestWithAllProfiles := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.4d401f,mp4a.40.2"
+http://existing.base/uri/avc_main_3.1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,mp4a.40.2"
+http://existing.base/uri/avc_high_4.0.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=8000,AVERAGE-BANDWIDTH=8000,CODECS="avc1.640033,mp4a.40.2"
+http://existing.base/uri/avc_high_5.1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="hvc1.1.6.L120.90,mp4a.40.2"
+http://existing.base/uri/hevc_main_4.0.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=6000,AVERAGE-BANDWIDTH=6000,CODECS="hvc1.2.4.L153.B0,mp4a.40.2"
+http://existing.base/uri/hevc_main10_5.1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	manifestUpToAVCLevel4 := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.4d401f,mp4a.40.2"
+http://existing.base/uri/avc_main_3.1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,mp4a.40.2"
+http://existing.base/uri/avc_high_4.0.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="hvc1.1.6.L120.90,mp4a.40.2"
+http://existing.base/uri/hevc_main_4.0.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=6000,AVERAGE-BANDWIDTH=6000,CODECS="hvc1.2.4.L153.B0,mp4a.40.2"
+http://existing.base/uri/hevc_main10_5.1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	manifestWithHEVCMainOnly := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.4d401f,mp4a.40.2"
+http://existing.base/uri/avc_main_3.1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,mp4a.40.2"
+http://existing.base/uri/avc_high_4.0.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=8000,AVERAGE-BANDWIDTH=8000,CODECS="avc1.640033,mp4a.40.2"
+http://existing.base/uri/avc_high_5.1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="hvc1.1.6.L120.90,mp4a.40.2"
+http://existing.base/uri/hevc_main_4.0.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	manifestForLegacyDevices := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.4d401f,mp4a.40.2"
+http://existing.base/uri/avc_main_3.1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="hvc1.1.6.L120.90,mp4a.40.2"
+http://existing.base/uri/hevc_main_4.0.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when capping avc at level 4.0, expect avc variants above it to be stripped out",
+			filters:               &parsers.MediaFilters{VideoLevels: map[codecs.Family]float64{codecs.FamilyAVC: 4}},
+			manifestContent:       manifestWithAllProfiles,
+			expectManifestContent: manifestUpToAVCLevel4,
+		},
+		{
+			name:                  "when allowing hevc main only, expect main10 variants to be stripped out",
+			filters:               &parsers.MediaFilters{VideoProfiles: map[codecs.Family][]string{codecs.FamilyHEVC: {"main"}}},
+			manifestContent:       manifestWithAllProfiles,
+			expectManifestContent: manifestWithHEVCMainOnly,
+		},
+		{
+			name: "when capping both profiles and levels, expect variants exceeding either to be stripped out",
+			filters: &parsers.MediaFilters{
+				VideoLevels:   map[codecs.Family]float64{codecs.FamilyAVC: 4, codecs.FamilyHEVC: 5.1},
+				VideoProfiles: map[codecs.Family][]string{codecs.FamilyHEVC: {"main"}, codecs.FamilyAVC: {"main", "baseline"}},
+			},
+			manifestContent:       manifestWithAllProfiles,
+			expectManifestContent: manifestForLegacyDevices,
+		},
+		{
+			name:                  "when capping at the highest levels in the manifest, expect unfiltered manifest",
+			filters:               &parsers.MediaFilters{VideoLevels: map[codecs.Family]float64{codecs.FamilyAVC: 5.1, codecs.FamilyHEVC: 5.1}},
+			manifestContent:       manifestWithAllProfiles,
+			expectManifestContent: manifestWithAllProfiles,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
 func TestHLSFilter_FilterManifest_CaptionsFilter(t *testing.T) {
 	manifestWithAllCaptions := `#EXTM3U
 #EXT-X-VERSION:3
@@ -767,6 +1082,121 @@ http://existing.base/uri/link_14.m3u8
 	}
 }
 
+func TestHLSFilter_FilterManifest_IncludeModeCodecFilter(t *testing.T) {
+	manifestWithAllCodecs := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2,wvtt"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1100,AVERAGE-BANDWIDTH=1100,CODECS="avc1.64001f,ec-3,stpp.ttml.im1t"
+http://existing.base/uri/link_2.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1200,AVERAGE-BANDWIDTH=1200,CODECS="hvc1.2.4.L93.B0,mp4a.40.2"
+http://existing.base/uri/link_3.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1300,AVERAGE-BANDWIDTH=1300,CODECS="dvh1.05.06,ec-3"
+http://existing.base/uri/link_4.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/link_5.m3u8
+`
+
+	manifestWithAVCOnly := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2,wvtt"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1100,AVERAGE-BANDWIDTH=1100,CODECS="avc1.64001f,ec-3,stpp.ttml.im1t"
+http://existing.base/uri/link_2.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/link_5.m3u8
+`
+
+	manifestWithAACOnly := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2,wvtt"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1200,AVERAGE-BANDWIDTH=1200,CODECS="hvc1.2.4.L93.B0,mp4a.40.2"
+http://existing.base/uri/link_3.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/link_5.m3u8
+`
+
+	manifestWithWebVTTOnly := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2,wvtt"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1200,AVERAGE-BANDWIDTH=1200,CODECS="hvc1.2.4.L93.B0,mp4a.40.2"
+http://existing.base/uri/link_3.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1300,AVERAGE-BANDWIDTH=1300,CODECS="dvh1.05.06,ec-3"
+http://existing.base/uri/link_4.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/link_5.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when including avc only, expect variants with other video codecs to be stripped out " +
+				"and audio only variants to be kept",
+			filters: &parsers.MediaFilters{
+				Videos:    []parsers.VideoType{"avc"},
+				VideoMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       manifestWithAllCodecs,
+			expectManifestContent: manifestWithAVCOnly,
+		},
+		{
+			name: "when including mp4a only, expect variants with other audio codecs to be stripped out",
+			filters: &parsers.MediaFilters{
+				Audios:    []parsers.AudioType{"mp4a"},
+				AudioMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       manifestWithAllCodecs,
+			expectManifestContent: manifestWithAACOnly,
+		},
+		{
+			name: "when including wvtt only, expect variants with other caption codecs to be stripped out",
+			filters: &parsers.MediaFilters{
+				CaptionTypes:    []parsers.CaptionType{"wvtt"},
+				CaptionTypeMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       manifestWithAllCodecs,
+			expectManifestContent: manifestWithWebVTTOnly,
+		},
+		{
+			name: "when including every video codec in the manifest, expect unfiltered manifest",
+			filters: &parsers.MediaFilters{
+				Videos:    []parsers.VideoType{"avc", "hvc", "dvh"},
+				VideoMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       manifestWithAllCodecs,
+			expectManifestContent: manifestWithAllCodecs,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
 func TestHLSFilter_FilterManifest_MultiFilter(t *testing.T) {
 
 	manifestWithAllCodecsAndBandwidths := `#EXTM3U
@@ -1103,3 +1533,2010 @@ http://existi\ng.base/uri/link_1.m3u8
 		})
 	}
 }
+
+func TestHLSFilter_FilterManifest_AudioLanguageFilter(t *testing.T) {
+	manifestWithAllAudioLanguages := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="Espanol",DEFAULT=NO,LANGUAGE="es-MX",URI="http://existing.base/uri/aac_es.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ec3",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/ec3_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.64001f,ec-3",AUDIO="ec3"
+http://existing.base/uri/link_2.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="avc1.640028,mp4a.40.2"
+http://existing.base/uri/link_3.m3u8
+`
+
+	manifestWithoutSpanish := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ec3",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/ec3_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.64001f,ec-3",AUDIO="ec3"
+http://existing.base/uri/link_2.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="avc1.640028,mp4a.40.2"
+http://existing.base/uri/link_3.m3u8
+`
+
+	manifestWithoutEnglish := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="Espanol",DEFAULT=NO,LANGUAGE="es-MX",URI="http://existing.base/uri/aac_es.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="avc1.640028,mp4a.40.2"
+http://existing.base/uri/link_3.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when no audio language filter is given, expect unfiltered manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithAllAudioLanguages,
+			expectManifestContent: manifestWithAllAudioLanguages,
+		},
+		{
+			name:                  "when filtering out es-MX, expect spanish renditions to be stripped out",
+			filters:               &parsers.MediaFilters{AudioLanguages: []parsers.AudioLanguage{"es-MX"}},
+			manifestContent:       manifestWithAllAudioLanguages,
+			expectManifestContent: manifestWithoutSpanish,
+		},
+		{
+			name: "when filtering out en, expect english renditions and the variants of emptied audio " +
+				"groups to be stripped out",
+			filters:               &parsers.MediaFilters{AudioLanguages: []parsers.AudioLanguage{"en"}},
+			manifestContent:       manifestWithAllAudioLanguages,
+			expectManifestContent: manifestWithoutEnglish,
+		},
+		{
+			name:                  "when filtering out a language with different casing, expect it to be matched",
+			filters:               &parsers.MediaFilters{AudioLanguages: []parsers.AudioLanguage{"ES-mx"}},
+			manifestContent:       manifestWithAllAudioLanguages,
+			expectManifestContent: manifestWithoutSpanish,
+		},
+		{
+			name:            "when filtering out every audio language, expect an error",
+			filters:         &parsers.MediaFilters{AudioLanguages: []parsers.AudioLanguage{"en", "es-MX"}},
+			manifestContent: manifestWithAllAudioLanguages,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			} else if err != nil && tt.expectErr {
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_AudioChannelsFilter(t *testing.T) {
+	manifestWithChannels := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",CHANNELS="2",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ec3",NAME="English",DEFAULT=YES,LANGUAGE="en",CHANNELS="6",URI="http://existing.base/uri/ec3_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="atmos",NAME="English",DEFAULT=YES,LANGUAGE="en",CHANNELS="16/JOC",URI="http://existing.base/uri/atmos_en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,AVERAGE-BANDWIDTH=5000000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/video_aac.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,ec-3",RESOLUTION=1920x1080,AUDIO="ec3"
+http://existing.base/uri/video_ec3.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5800000,AVERAGE-BANDWIDTH=5800000,CODECS="avc1.640028,ec-3",RESOLUTION=1920x1080,AUDIO="atmos"
+http://existing.base/uri/video_atmos.m3u8
+`
+
+	manifestWithoutJOC := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",CHANNELS="2",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ec3",NAME="English",DEFAULT=YES,LANGUAGE="en",CHANNELS="6",URI="http://existing.base/uri/ec3_en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,AVERAGE-BANDWIDTH=5000000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/video_aac.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,ec-3",RESOLUTION=1920x1080,AUDIO="ec3"
+http://existing.base/uri/video_ec3.m3u8
+`
+
+	manifestWithStereoOnly := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",CHANNELS="2",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,AVERAGE-BANDWIDTH=5000000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/video_aac.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when excluding joint object coding, expect atmos renditions and their variants to be " +
+				"stripped out",
+			filters:               &parsers.MediaFilters{ExcludeJOC: true},
+			manifestContent:       manifestWithChannels,
+			expectManifestContent: manifestWithoutJOC,
+		},
+		{
+			name:                  "when capping channels to 5.1, expect renditions above 6 channels to be stripped out",
+			filters:               &parsers.MediaFilters{MaxAudioChannels: 6},
+			manifestContent:       manifestWithChannels,
+			expectManifestContent: manifestWithoutJOC,
+		},
+		{
+			name:                  "when keeping stereo only, expect surround renditions to be stripped out",
+			filters:               &parsers.MediaFilters{MaxAudioChannels: 2},
+			manifestContent:       manifestWithChannels,
+			expectManifestContent: manifestWithStereoOnly,
+		},
+		{
+			name:            "when every audio rendition is stripped out, expect an error",
+			filters:         &parsers.MediaFilters{MaxAudioChannels: 1},
+			manifestContent: manifestWithChannels,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_AudioDescriptionFilter(t *testing.T) {
+	manifestWithAudioDescription := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,AUTOSELECT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English (Audio Description)",DEFAULT=NO,AUTOSELECT=YES,LANGUAGE="en",CHARACTERISTICS="public.accessibility.describes-video",URI="http://existing.base/uri/aac_en_ad.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,AVERAGE-BANDWIDTH=5000000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/video.m3u8
+`
+
+	manifestWithoutAudioDescription := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,AUTOSELECT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,AVERAGE-BANDWIDTH=5000000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/video.m3u8
+`
+
+	manifestWithAudioDescriptionOnly := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English (Audio Description)",DEFAULT=NO,AUTOSELECT=YES,LANGUAGE="en",CHARACTERISTICS="public.accessibility.describes-video",URI="http://existing.base/uri/aac_en_ad.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,AVERAGE-BANDWIDTH=5000000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/video.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when excluding audio description, expect described video renditions to be stripped out",
+			filters:               &parsers.MediaFilters{AudioDescription: parsers.AudioDescriptionExclude},
+			manifestContent:       manifestWithAudioDescription,
+			expectManifestContent: manifestWithoutAudioDescription,
+		},
+		{
+			name:                  "when keeping audio description only, expect every other audio rendition to be stripped out",
+			filters:               &parsers.MediaFilters{AudioDescription: parsers.AudioDescriptionOnly},
+			manifestContent:       manifestWithAudioDescription,
+			expectManifestContent: manifestWithAudioDescriptionOnly,
+		},
+		{
+			name:            "when keeping audio description only and there is none, expect an error",
+			filters:         &parsers.MediaFilters{AudioDescription: parsers.AudioDescriptionOnly},
+			manifestContent: manifestWithoutAudioDescription,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_CaptionLanguageFilter(t *testing.T) {
+	manifestWithAllCaptionLanguages := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",DEFAULT=YES,AUTOSELECT=YES,LANGUAGE="en",URI="http://existing.base/uri/subs_en.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="Espanol",DEFAULT=NO,AUTOSELECT=YES,LANGUAGE="es-MX",URI="http://existing.base/uri/subs_es.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="forced",NAME="Espanol",DEFAULT=NO,AUTOSELECT=YES,LANGUAGE="es-MX",FORCED="YES",URI="http://existing.base/uri/forced_es.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",SUBTITLES="subs"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.640028,mp4a.40.2",SUBTITLES="forced"
+http://existing.base/uri/link_2.m3u8
+`
+
+	manifestWithoutSpanishCaptions := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",DEFAULT=YES,AUTOSELECT=YES,LANGUAGE="en",URI="http://existing.base/uri/subs_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",SUBTITLES="subs"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.640028,mp4a.40.2"
+http://existing.base/uri/link_2.m3u8
+`
+
+	manifestWithoutCaptions := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.640028,mp4a.40.2"
+http://existing.base/uri/link_2.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when no caption language filter is given, expect unfiltered manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithAllCaptionLanguages,
+			expectManifestContent: manifestWithAllCaptionLanguages,
+		},
+		{
+			name: "when filtering out es-MX, expect spanish subtitles and references to emptied groups " +
+				"to be stripped out",
+			filters:               &parsers.MediaFilters{CaptionLanguages: []parsers.CaptionLanguage{"es-MX"}},
+			manifestContent:       manifestWithAllCaptionLanguages,
+			expectManifestContent: manifestWithoutSpanishCaptions,
+		},
+		{
+			name:                  "when filtering out every caption language, expect variants without subtitles",
+			filters:               &parsers.MediaFilters{CaptionLanguages: []parsers.CaptionLanguage{"en", "es-MX"}},
+			manifestContent:       manifestWithAllCaptionLanguages,
+			expectManifestContent: manifestWithoutCaptions,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_ClosedCaptionsFilter(t *testing.T) {
+	manifestWithClosedCaptions := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="English",DEFAULT=YES,AUTOSELECT=YES,LANGUAGE="en",INSTREAM-ID="CC1"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="Español",DEFAULT=NO,AUTOSELECT=YES,LANGUAGE="es",INSTREAM-ID="CC3"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,CLOSED-CAPTIONS="cc"
+http://existing.base/uri/720p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,CLOSED-CAPTIONS="cc"
+http://existing.base/uri/1080p.m3u8
+`
+
+	manifestWithoutSpanish := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="English",DEFAULT=YES,AUTOSELECT=YES,LANGUAGE="en",INSTREAM-ID="CC1"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,CLOSED-CAPTIONS="cc"
+http://existing.base/uri/720p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,CLOSED-CAPTIONS="cc"
+http://existing.base/uri/1080p.m3u8
+`
+
+	manifestWithoutClosedCaptions := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,CLOSED-CAPTIONS=NONE
+http://existing.base/uri/720p.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,CLOSED-CAPTIONS=NONE
+http://existing.base/uri/1080p.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when filtering an instream id, expect its closed captions rendition to be stripped out",
+			filters:               &parsers.MediaFilters{ClosedCaptions: []parsers.ClosedCaption{"CC3"}},
+			manifestContent:       manifestWithClosedCaptions,
+			expectManifestContent: manifestWithoutSpanish,
+		},
+		{
+			name:                  "when filtering a language, expect its closed captions rendition to be stripped out",
+			filters:               &parsers.MediaFilters{ClosedCaptions: []parsers.ClosedCaption{"es"}},
+			manifestContent:       manifestWithClosedCaptions,
+			expectManifestContent: manifestWithoutSpanish,
+		},
+		{
+			name: "when only an instream id is included, expect every other closed captions rendition to be " +
+				"stripped out",
+			filters: &parsers.MediaFilters{
+				ClosedCaptions:    []parsers.ClosedCaption{"cc1"},
+				ClosedCaptionMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       manifestWithClosedCaptions,
+			expectManifestContent: manifestWithoutSpanish,
+		},
+		{
+			name: "when every closed captions rendition is stripped out, expect variants to have their " +
+				"CLOSED-CAPTIONS set to NONE",
+			filters:               &parsers.MediaFilters{ClosedCaptions: []parsers.ClosedCaption{"en", "es"}},
+			manifestContent:       manifestWithClosedCaptions,
+			expectManifestContent: manifestWithoutClosedCaptions,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_DRMSystemFilter(t *testing.T) {
+	manifestWithSessionKeys := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI="skd://key-id",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES-CTR,URI="data:text/plain;base64,AAAAW3Bzc2g=",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES-CTR,URI="data:text/plain;charset=UTF-16;base64,xAEAAAEAAQ==",KEYFORMAT="com.microsoft.playready",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=AES-128,URI="https://keys.example.com/key"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/720p.m3u8
+`
+
+	manifestWithFairPlayKeys := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI="skd://key-id",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=AES-128,URI="https://keys.example.com/key"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/720p.m3u8
+`
+
+	manifestWithoutFairPlayKeys := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES-CTR,URI="data:text/plain;base64,AAAAW3Bzc2g=",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES-CTR,URI="data:text/plain;charset=UTF-16;base64,xAEAAAEAAQ==",KEYFORMAT="com.microsoft.playready",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=AES-128,URI="https://keys.example.com/key"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/720p.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when keeping a drm system, expect the session keys of every other system to be stripped " +
+				"out and keys without a key format to be kept",
+			filters:               &parsers.MediaFilters{DRMSystems: []parsers.DRMSystem{parsers.DRMSystemFairPlay}},
+			manifestContent:       manifestWithSessionKeys,
+			expectManifestContent: manifestWithFairPlayKeys,
+		},
+		{
+			name: "when keeping several drm systems, expect the session keys of the others to be stripped out",
+			filters: &parsers.MediaFilters{
+				DRMSystems: []parsers.DRMSystem{parsers.DRMSystemWidevine, parsers.DRMSystemPlayReady},
+			},
+			manifestContent:       manifestWithSessionKeys,
+			expectManifestContent: manifestWithoutFairPlayKeys,
+		},
+		{
+			name:                  "when no drm filter is given, expect every session key to be kept",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithSessionKeys,
+			expectManifestContent: manifestWithSessionKeys,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_KeyURIRewrite(t *testing.T) {
+	manifestWithKeys := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI="skd://key-id",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES-CTR,URI="data:text/plain;base64,AAAAW3Bzc2g=",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES-CTR,URI="https://playready.example.com/rightsmanager.asmx",KEYFORMAT="com.microsoft.playready",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=AES-128,URI="keys/aes.key"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/720p.m3u8
+`
+
+	manifestWithRewrittenKeys := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI="skd://key-id",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES-CTR,URI="data:text/plain;base64,AAAAW3Bzc2g=",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES-CTR,URI="https://license.proxy/playready?uri=https%3A%2F%2Fplayready.example.com%2Frightsmanager.asmx",KEYFORMAT="com.microsoft.playready",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=AES-128,URI="https://license.proxy/identity?uri=http%3A%2F%2Fexisting.base%2Furi%2Fkeys%2Faes.key"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/720p.m3u8
+`
+
+	manifestWithAbsoluteKeys := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI="skd://key-id",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES-CTR,URI="data:text/plain;base64,AAAAW3Bzc2g=",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES-CTR,URI="https://playready.example.com/rightsmanager.asmx",KEYFORMAT="com.microsoft.playready",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=AES-128,URI="http://other.base/uri/keys/aes.key"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/720p.m3u8
+`
+
+	mediaPlaylistWithKeys := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-TARGETDURATION:6
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key-id",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXTINF:6.000,
+http://existing.base/uri/segment_0.ts
+#EXT-X-KEY:METHOD=AES-128,URI="http://existing.base/uri/keys/aes.key"
+#EXTINF:6.000,
+http://existing.base/uri/segment_1.ts
+#EXT-X-ENDLIST
+`
+
+	mediaPlaylistWithRewrittenKeys := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-TARGETDURATION:6
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key-id",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXTINF:6.000,
+http://existing.base/uri/segment_0.ts
+#EXT-X-KEY:METHOD=AES-128,URI="https://license.proxy/identity?uri=http%3A%2F%2Fexisting.base%2Furi%2Fkeys%2Faes.key"
+#EXTINF:6.000,
+http://existing.base/uri/segment_1.ts
+#EXT-X-ENDLIST
+`
+
+	licenseURLs := config.LicenseURLTemplates{"existing.base": "https://license.proxy/{drm}?uri={uri}"}
+
+	tests := []struct {
+		name                  string
+		manifestURL           string
+		licenseURLs           config.LicenseURLTemplates
+		manifestContent       string
+		expectManifestContent string
+	}{
+		{
+			name: "when a license url is configured for the origin, expect key uris to be rewritten, except " +
+				"for keys carried in data uris and fairplay skd uris",
+			manifestURL:           "http://existing.base/uri/master.m3u8",
+			licenseURLs:           licenseURLs,
+			manifestContent:       manifestWithKeys,
+			expectManifestContent: manifestWithRewrittenKeys,
+		},
+		{
+			name: "when a license url is configured for the origin of a media playlist, expect http key uris " +
+				"to be rewritten and fairplay skd uris to be kept",
+			manifestURL:           "http://existing.base/uri/720p.m3u8",
+			licenseURLs:           licenseURLs,
+			manifestContent:       mediaPlaylistWithKeys,
+			expectManifestContent: mediaPlaylistWithRewrittenKeys,
+		},
+		{
+			name: "when no license url is configured for the origin, expect key uris to be kept, only made " +
+				"absolute",
+			manifestURL:           "http://other.base/uri/master.m3u8",
+			licenseURLs:           licenseURLs,
+			manifestContent:       manifestWithKeys,
+			expectManifestContent: manifestWithAbsoluteKeys,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter(tt.manifestURL, tt.manifestContent, config.Config{LicenseURLs: tt.licenseURLs})
+			manifest, err := filter.FilterManifest(&parsers.MediaFilters{})
+			if err != nil {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_MediaPlaylist(t *testing.T) {
+	mediaPlaylist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="keys/segment.key",IV=0x00000000000000000000000000000001
+#EXTINF:6.000,
+segment_0.m4s
+#EXTINF:6.000,
+segment_1.m4s
+#EXT-X-DISCONTINUITY
+#EXTINF:4.500,
+http://other.base/uri/ad_0.m4s
+#EXT-X-ENDLIST
+`
+
+	mediaPlaylistWithAbsoluteURIs := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-MAP:URI="http://existing.base/uri/init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="http://existing.base/uri/keys/segment.key",IV=0x00000000000000000000000000000001
+#EXTINF:6.000,
+http://existing.base/uri/segment_0.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_1.m4s
+#EXT-X-DISCONTINUITY
+#EXTINF:4.500,
+http://other.base/uri/ad_0.m4s
+#EXT-X-ENDLIST
+`
+
+	filter := NewHLSFilter("http://existing.base/uri/720p.m3u8", mediaPlaylist, config.Config{})
+	manifest, err := filter.FilterManifest(&parsers.MediaFilters{})
+	if err != nil {
+		t.Fatalf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+	}
+
+	if g, e := manifest, mediaPlaylistWithAbsoluteURIs; g != e {
+		t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+			cmp.Diff(g, e))
+	}
+}
+
+func TestHLSFilter_FilterManifest_ProxiedPlaylists(t *testing.T) {
+	masterManifest := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="aac_en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,AUDIO="aac"
+720p.m3u8?token=abc
+#EXT-X-STREAM-INF:BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://other.base/uri/1080p.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=300000,CODECS="avc1.64001f",RESOLUTION=1280x720,URI="iframes_720p.m3u8"
+`
+
+	manifestWithProxiedURIs := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="https://bakery.example.com/b(0,6000)/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,AUDIO="aac"
+https://bakery.example.com/b(0,6000)/uri/720p.m3u8?token=abc
+#EXT-X-STREAM-INF:BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://other.base/uri/1080p.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=300000,CODECS="avc1.64001f",RESOLUTION=1280x720,URI="https://bakery.example.com/b(0,6000)/uri/iframes_720p.m3u8"
+`
+
+	manifestWithAbsoluteURIs := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=2200000,AVERAGE-BANDWIDTH=2200000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,AUDIO="aac"
+http://existing.base/uri/720p.m3u8?token=abc
+#EXT-X-STREAM-INF:BANDWIDTH=5400000,AVERAGE-BANDWIDTH=5400000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://other.base/uri/1080p.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=300000,CODECS="avc1.64001f",RESOLUTION=1280x720,URI="http://existing.base/uri/iframes_720p.m3u8"
+`
+
+	tests := []struct {
+		name                  string
+		publicURL             string
+		expectManifestContent string
+	}{
+		{
+			name: "when a public url is configured, expect playlists served by the origin to be requested " +
+				"through bakery with the same filters",
+			publicURL:             "https://bakery.example.com/",
+			expectManifestContent: manifestWithProxiedURIs,
+		},
+		{
+			name:                  "when no public url is configured, expect playlists to be requested from the origin",
+			expectManifestContent: manifestWithAbsoluteURIs,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.Config{OriginHost: "http://existing.base", PublicURL: tt.publicURL}
+			filter := NewHLSFilter("http://existing.base/uri/master.m3u8", masterManifest, c)
+			manifest, err := filter.FilterManifest(&parsers.MediaFilters{
+				FilterPath: "/b(0,6000)",
+				MaxBitrate: 6000000,
+			})
+			if err != nil {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_TimeClip(t *testing.T) {
+	vodPlaylist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-MAP:URI="http://existing.base/uri/init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="http://existing.base/uri/segment.key"
+#EXTINF:6.000,
+http://existing.base/uri/segment_0.m4s
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+http://existing.base/uri/segment_1.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_2.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_3.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_4.m4s
+#EXT-X-ENDLIST
+`
+
+	clippedPlaylist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:2
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-DISCONTINUITY-SEQUENCE:1
+#EXT-X-MAP:URI="http://existing.base/uri/init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="http://existing.base/uri/segment.key"
+#EXTINF:6.000,
+http://existing.base/uri/segment_2.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_3.m4s
+#EXT-X-ENDLIST
+`
+
+	playlistStart := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-MAP:URI="http://existing.base/uri/init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="http://existing.base/uri/segment.key"
+#EXTINF:6.000,
+http://existing.base/uri/segment_0.m4s
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+http://existing.base/uri/segment_1.m4s
+#EXT-X-ENDLIST
+`
+
+	livePlaylist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-MAP:URI="http://existing.base/uri/init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="http://existing.base/uri/segment.key"
+#EXTINF:6.000,
+http://existing.base/uri/segment_0.m4s
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+http://existing.base/uri/segment_1.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_2.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_3.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_4.m4s
+`
+
+	vodPlaylistWithDateTime := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-PROGRAM-DATE-TIME:2020-05-01T10:00:00.000Z
+#EXTINF:6.000,
+http://existing.base/uri/segment_0.ts
+#EXTINF:6.000,
+http://existing.base/uri/segment_1.ts
+#EXTINF:5.500,
+http://existing.base/uri/segment_2.ts
+#EXTINF:6.000,
+http://existing.base/uri/segment_3.ts
+#EXT-X-ENDLIST
+`
+
+	clippedPlaylistWithDateTime := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:2
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-PROGRAM-DATE-TIME:2020-05-01T10:00:12.000Z
+#EXTINF:5.500,
+http://existing.base/uri/segment_2.ts
+#EXTINF:6.000,
+http://existing.base/uri/segment_3.ts
+#EXT-X-ENDLIST
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when clipping a vod playlist, expect the segments covering the clip to be kept along with " +
+				"the keys and initialization sections applying to them",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 13 * time.Second, End: 20 * time.Second},
+			},
+			manifestContent:       vodPlaylist,
+			expectManifestContent: clippedPlaylist,
+		},
+		{
+			name: "when clipping the start of a vod playlist, expect the segments after the clip to be " +
+				"stripped out",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 0, End: 12 * time.Second},
+			},
+			manifestContent:       vodPlaylist,
+			expectManifestContent: playlistStart,
+		},
+		{
+			name: "when clipping a vod playlist whose program date time is declared before the clip, expect " +
+				"it to be carried over to the first segment kept",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 13 * time.Second, End: 24 * time.Second},
+			},
+			manifestContent:       vodPlaylistWithDateTime,
+			expectManifestContent: clippedPlaylistWithDateTime,
+		},
+		{
+			name: "when clipping past the end of a vod playlist, expect an error",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 40 * time.Second, End: 50 * time.Second},
+			},
+			manifestContent: vodPlaylist,
+			expectErr:       true,
+		},
+		{
+			name: "when clipping a live playlist, expect an error",
+			filters: &parsers.MediaFilters{
+				Clip: &parsers.TimeRange{Start: 0, End: 12 * time.Second},
+			},
+			manifestContent: livePlaylist,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_DVRWindow(t *testing.T) {
+	livePlaylist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-MAP:URI="http://existing.base/uri/init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="http://existing.base/uri/segment.key"
+#EXTINF:6.000,
+http://existing.base/uri/segment_100.m4s
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+http://existing.base/uri/segment_101.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_102.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_103.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_104.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_105.m4s
+`
+
+	trimmedPlaylist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:104
+#EXT-X-DISCONTINUITY-SEQUENCE:1
+#EXT-X-MAP:URI="http://existing.base/uri/init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="http://existing.base/uri/segment.key"
+#EXTINF:6.000,
+http://existing.base/uri/segment_104.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_105.m4s
+`
+
+	eventPlaylist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-PLAYLIST-TYPE:EVENT
+#EXT-X-MAP:URI="http://existing.base/uri/init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="http://existing.base/uri/segment.key"
+#EXTINF:6.000,
+http://existing.base/uri/segment_100.m4s
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+http://existing.base/uri/segment_101.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_102.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_103.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_104.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_105.m4s
+`
+
+	vodPlaylist := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-PLAYLIST-TYPE:VOD
+#EXT-X-MAP:URI="http://existing.base/uri/init.mp4"
+#EXT-X-KEY:METHOD=AES-128,URI="http://existing.base/uri/segment.key"
+#EXTINF:6.000,
+http://existing.base/uri/segment_100.m4s
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+http://existing.base/uri/segment_101.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_102.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_103.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_104.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_105.m4s
+#EXT-X-ENDLIST
+`
+
+	commentedLivePlaylist := `#EXTM3U
+## packaged by origin
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:100
+#EXTINF:6.000,
+http://existing.base/uri/segment_100.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_101.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_102.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_103.m4s
+`
+
+	trimmedCommentedPlaylist := `#EXTM3U
+## packaged by origin
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:102
+#EXTINF:6.000,
+http://existing.base/uri/segment_102.m4s
+#EXTINF:6.000,
+http://existing.base/uri/segment_103.m4s
+`
+
+	livePlaylistWithByteRanges := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-PROGRAM-DATE-TIME:2020-05-01T10:00:00.000Z
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:1000@0
+http://existing.base/uri/segments.ts
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:1200
+http://existing.base/uri/segments.ts
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:900
+http://existing.base/uri/segments.ts
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:1100
+http://existing.base/uri/segments.ts
+`
+
+	trimmedPlaylistWithByteRanges := `#EXTM3U
+#EXT-X-VERSION:7
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:102
+#EXT-X-PROGRAM-DATE-TIME:2020-05-01T10:00:12.000Z
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:900@2200
+http://existing.base/uri/segments.ts
+#EXTINF:6.000,
+#EXT-X-BYTERANGE:1100
+http://existing.base/uri/segments.ts
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when shortening the dvr window of a live playlist, expect the oldest segments to be " +
+				"stripped out and the sequence numbers to be moved past them",
+			filters:               &parsers.MediaFilters{DVRWindow: 13 * time.Second},
+			manifestContent:       livePlaylist,
+			expectManifestContent: trimmedPlaylist,
+		},
+		{
+			name: "when shortening the dvr window of an event playlist, expect it to become a sliding " +
+				"window playlist",
+			filters:               &parsers.MediaFilters{DVRWindow: 13 * time.Second},
+			manifestContent:       eventPlaylist,
+			expectManifestContent: trimmedPlaylist,
+		},
+		{
+			name: "when a comment precedes the playlist tags, expect them to be kept in the header and the " +
+				"media sequence number to be moved past the stripped out segments",
+			filters:               &parsers.MediaFilters{DVRWindow: 12 * time.Second},
+			manifestContent:       commentedLivePlaylist,
+			expectManifestContent: trimmedCommentedPlaylist,
+		},
+		{
+			name: "when shortening the dvr window of a live playlist, expect the program date time and the " +
+				"byte range offset implied by the stripped out segments to be carried over to the first " +
+				"segment kept",
+			filters:               &parsers.MediaFilters{DVRWindow: 12 * time.Second},
+			manifestContent:       livePlaylistWithByteRanges,
+			expectManifestContent: trimmedPlaylistWithByteRanges,
+		},
+		{
+			name:                  "when the dvr window is longer than the playlist, expect every segment to be kept",
+			filters:               &parsers.MediaFilters{DVRWindow: time.Hour},
+			manifestContent:       livePlaylist,
+			expectManifestContent: livePlaylist,
+		},
+		{
+			name:                  "when setting the dvr window of a vod playlist, expect every segment to be kept",
+			filters:               &parsers.MediaFilters{DVRWindow: 13 * time.Second},
+			manifestContent:       vodPlaylist,
+			expectManifestContent: vodPlaylist,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_StreamTypeFilter(t *testing.T) {
+	manifestWithAllStreamTypes := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",DEFAULT=NO,LANGUAGE="en",URI="http://existing.base/uri/subs_en.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="English",DEFAULT=NO,LANGUAGE="en"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",AUDIO="aac",CLOSED-CAPTIONS="cc",SUBTITLES="subs"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.640028,mp4a.40.2",AUDIO="aac",CLOSED-CAPTIONS="cc",SUBTITLES="subs"
+http://existing.base/uri/link_2.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/aac_en.m3u8
+#EXT-X-I-FRAME-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=300,CODECS="avc1.64001f",URI="http://existing.base/uri/iframe_1.m3u8"
+`
+
+	manifestWithoutText := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.640028,mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/link_2.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/aac_en.m3u8
+#EXT-X-I-FRAME-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=300,CODECS="avc1.64001f",URI="http://existing.base/uri/iframe_1.m3u8"
+`
+
+	manifestWithoutAudio := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",DEFAULT=NO,LANGUAGE="en",URI="http://existing.base/uri/subs_en.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="English",DEFAULT=NO,LANGUAGE="en"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f",CLOSED-CAPTIONS="cc",SUBTITLES="subs"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.640028",CLOSED-CAPTIONS="cc",SUBTITLES="subs"
+http://existing.base/uri/link_2.m3u8
+#EXT-X-I-FRAME-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=300,CODECS="avc1.64001f",URI="http://existing.base/uri/iframe_1.m3u8"
+`
+
+	manifestAudioOnlyFirst := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/aac_en.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.640028,mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/link_2.m3u8
+#EXT-X-I-FRAME-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=300,CODECS="avc1.64001f",URI="http://existing.base/uri/iframe_1.m3u8"
+`
+
+	manifestWithoutVideo := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/aac_en.m3u8
+`
+
+	manifestWithoutAudioOnlyVariant := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="Spanish",DEFAULT=NO,LANGUAGE="es",URI="http://existing.base/uri/aac_es.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.640028,mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/link_2.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/link_1.m3u8
+`
+
+	manifestWithBuiltAudioOnlyVariant := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="Spanish",DEFAULT=NO,LANGUAGE="es",URI="http://existing.base/uri/aac_es.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1000,CODECS="mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/aac_en.m3u8
+`
+
+	manifestWithMuxedAudio := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.640028,mp4a.40.2"
+http://existing.base/uri/link_2.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when no stream type filter is given, expect unfiltered manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithAllStreamTypes,
+			expectManifestContent: manifestWithAllStreamTypes,
+		},
+		{
+			name: "when filtering out text, expect subtitles and closed captions renditions and their " +
+				"variant attributes to be stripped out",
+			filters:               &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"text"}},
+			manifestContent:       manifestWithAllStreamTypes,
+			expectManifestContent: manifestWithoutText,
+		},
+		{
+			name: "when filtering out audio, expect audio renditions, audio codecs and audio only " +
+				"variants to be stripped out",
+			filters:               &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"audio"}},
+			manifestContent:       manifestWithAllStreamTypes,
+			expectManifestContent: manifestWithoutAudio,
+		},
+		{
+			name:                  "when filtering out video, expect an audio only manifest",
+			filters:               &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"video"}},
+			manifestContent:       manifestAudioOnlyFirst,
+			expectManifestContent: manifestWithoutVideo,
+		},
+		{
+			name: "when filtering out video of a manifest without audio only variants, expect an audio only " +
+				"variant playing the default rendition of each audio group to be built",
+			filters:               &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"video"}},
+			manifestContent:       manifestWithoutAudioOnlyVariant,
+			expectManifestContent: manifestWithBuiltAudioOnlyVariant,
+		},
+		{
+			name: "when filtering out video of a manifest whose audio is muxed with video, expect an error " +
+				"rather than an empty manifest",
+			filters:         &parsers.MediaFilters{FilterStreamTypes: []parsers.StreamType{"video"}},
+			manifestContent: manifestWithMuxedAudio,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_ResolutionFilter(t *testing.T) {
+	manifestWithAllResolutions := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001e,mp4a.40.2",RESOLUTION=854x480
+http://existing.base/uri/link_480.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/link_1080.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=12000,AVERAGE-BANDWIDTH=12000,CODECS="avc1.640033,mp4a.40.2",RESOLUTION=3840x2160
+http://existing.base/uri/link_2160.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=1200,CODECS="avc1.640033",RESOLUTION=3840x2160,URI="http://existing.base/uri/iframes_2160.m3u8"
+`
+
+	manifestUpTo1080p := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001e,mp4a.40.2",RESOLUTION=854x480
+http://existing.base/uri/link_480.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/link_1080.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	manifestFrom1080p := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/link_1080.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=12000,AVERAGE-BANDWIDTH=12000,CODECS="avc1.640033,mp4a.40.2",RESOLUTION=3840x2160
+http://existing.base/uri/link_2160.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=1200,CODECS="avc1.640033",RESOLUTION=3840x2160,URI="http://existing.base/uri/iframes_2160.m3u8"
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when no resolution filter is given, expect unfiltered manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithAllResolutions,
+			expectManifestContent: manifestWithAllResolutions,
+		},
+		{
+			name: "when a maximum height is given, expect variants and i-frame variants above it to be " +
+				"stripped out and variants without a resolution to be kept",
+			filters:               &parsers.MediaFilters{MaxHeight: 1080},
+			manifestContent:       manifestWithAllResolutions,
+			expectManifestContent: manifestUpTo1080p,
+		},
+		{
+			name:                  "when a minimum height is given, expect variants below it to be stripped out",
+			filters:               &parsers.MediaFilters{MinHeight: 1080},
+			manifestContent:       manifestWithAllResolutions,
+			expectManifestContent: manifestFrom1080p,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_FrameRateFilter(t *testing.T) {
+	manifestWithAllFrameRates := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.64001f,mp4a.40.2",FRAME-RATE=29.970
+http://existing.base/uri/link_30fps.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,mp4a.40.2",FRAME-RATE=59.940
+http://existing.base/uri/link_60fps.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	manifestUpTo30fps := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2000,AVERAGE-BANDWIDTH=2000,CODECS="avc1.64001f,mp4a.40.2",FRAME-RATE=29.970
+http://existing.base/uri/link_30fps.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=200,AVERAGE-BANDWIDTH=200,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when no frame rate filter is given, expect unfiltered manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithAllFrameRates,
+			expectManifestContent: manifestWithAllFrameRates,
+		},
+		{
+			name: "when filtering above 30fps, expect variants above it to be stripped out and variants " +
+				"without a frame rate to be kept",
+			filters:               &parsers.MediaFilters{MaxFrameRate: 30},
+			manifestContent:       manifestWithAllFrameRates,
+			expectManifestContent: manifestUpTo30fps,
+		},
+		{
+			name:                  "when filtering above 60fps, expect unfiltered manifest",
+			filters:               &parsers.MediaFilters{MaxFrameRate: 60},
+			manifestContent:       manifestWithAllFrameRates,
+			expectManifestContent: manifestWithAllFrameRates,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_DynamicRangeFilter(t *testing.T) {
+	manifestWithDynamicRanges := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,AVERAGE-BANDWIDTH=5000000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/avc_sdr.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=8000000,AVERAGE-BANDWIDTH=8000000,CODECS="hvc1.2.4.L150.B0,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=PQ
+http://existing.base/uri/hevc_pq.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=8000000,AVERAGE-BANDWIDTH=8000000,CODECS="hvc1.2.4.L150.B0,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=HLG
+http://existing.base/uri/hevc_hlg.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=9000000,AVERAGE-BANDWIDTH=9000000,CODECS="dvh1.05.06,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=PQ
+http://existing.base/uri/dovi_p5.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=9000000,AVERAGE-BANDWIDTH=9000000,CODECS="dvh1.08.06,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=PQ
+http://existing.base/uri/dovi_p81.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=128000,AVERAGE-BANDWIDTH=128000,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	manifestWithSDROnly := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,AVERAGE-BANDWIDTH=5000000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/avc_sdr.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=128000,AVERAGE-BANDWIDTH=128000,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	manifestWithoutDolbyVisionOnly := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,AVERAGE-BANDWIDTH=5000000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/avc_sdr.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=8000000,AVERAGE-BANDWIDTH=8000000,CODECS="hvc1.2.4.L150.B0,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=PQ
+http://existing.base/uri/hevc_pq.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=8000000,AVERAGE-BANDWIDTH=8000000,CODECS="hvc1.2.4.L150.B0,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=HLG
+http://existing.base/uri/hevc_hlg.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=9000000,AVERAGE-BANDWIDTH=9000000,CODECS="dvh1.08.06,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=PQ
+http://existing.base/uri/dovi_p81.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=128000,AVERAGE-BANDWIDTH=128000,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	manifestWithPQOnly := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-STREAM-INF:BANDWIDTH=8000000,AVERAGE-BANDWIDTH=8000000,CODECS="hvc1.2.4.L150.B0,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=PQ
+http://existing.base/uri/hevc_pq.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=9000000,AVERAGE-BANDWIDTH=9000000,CODECS="dvh1.08.06,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=PQ
+http://existing.base/uri/dovi_p81.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=128000,AVERAGE-BANDWIDTH=128000,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	manifestWithDolbyVisionOnly := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-STREAM-INF:BANDWIDTH=9000000,AVERAGE-BANDWIDTH=9000000,CODECS="dvh1.05.06,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=PQ
+http://existing.base/uri/dovi_p5.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=9000000,AVERAGE-BANDWIDTH=9000000,CODECS="dvh1.08.06,mp4a.40.2",RESOLUTION=3840x2160,VIDEO-RANGE=PQ
+http://existing.base/uri/dovi_p81.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=128000,AVERAGE-BANDWIDTH=128000,CODECS="mp4a.40.2"
+http://existing.base/uri/audio.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name: "when filtering every hdr range, expect only sdr and audio only variants to be kept",
+			filters: &parsers.MediaFilters{DynamicRanges: []parsers.DynamicRange{
+				parsers.DynamicRangePQ, parsers.DynamicRangeHLG, parsers.DynamicRangeDolbyVision,
+			}},
+			manifestContent:       manifestWithDynamicRanges,
+			expectManifestContent: manifestWithSDROnly,
+		},
+		{
+			name: "when filtering dolby vision, expect profile 5 variants to be stripped out and cross " +
+				"compatible profile 8.1 variants to be kept",
+			filters:               &parsers.MediaFilters{DynamicRanges: []parsers.DynamicRange{parsers.DynamicRangeDolbyVision}},
+			manifestContent:       manifestWithDynamicRanges,
+			expectManifestContent: manifestWithoutDolbyVisionOnly,
+		},
+		{
+			name: "when only sdr is included, expect variants without a VIDEO-RANGE to be kept as sdr",
+			filters: &parsers.MediaFilters{
+				DynamicRanges:    []parsers.DynamicRange{parsers.DynamicRangeSDR},
+				DynamicRangeMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       manifestWithDynamicRanges,
+			expectManifestContent: manifestWithSDROnly,
+		},
+		{
+			name: "when only pq is included, expect hdr10 and dolby vision profile 8.1 variants to be kept",
+			filters: &parsers.MediaFilters{
+				DynamicRanges:    []parsers.DynamicRange{parsers.DynamicRangePQ},
+				DynamicRangeMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       manifestWithDynamicRanges,
+			expectManifestContent: manifestWithPQOnly,
+		},
+		{
+			name: "when only dolby vision is included, expect every dolby vision profile to be kept",
+			filters: &parsers.MediaFilters{
+				DynamicRanges:    []parsers.DynamicRange{parsers.DynamicRangeDolbyVision},
+				DynamicRangeMode: parsers.FilterModeInclude,
+			},
+			manifestContent:       manifestWithDynamicRanges,
+			expectManifestContent: manifestWithDolbyVisionOnly,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_ContentBitrateFilter(t *testing.T) {
+	manifestWithAudioGroups := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ec3",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/ec3_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2128000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,AUDIO="aac"
+http://existing.base/uri/avc_720_aac.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2384000,CODECS="avc1.64001f,ec-3",RESOLUTION=1280x720,AUDIO="ec3"
+http://existing.base/uri/avc_720_ec3.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=6128000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/avc_1080_aac.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=128000,CODECS="mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/aac_en.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=384000,CODECS="ec-3",AUDIO="ec3"
+http://existing.base/uri/ec3_en.m3u8
+`
+
+	manifestWithoutHighBitrateAudio := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2128000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,AUDIO="aac"
+http://existing.base/uri/avc_720_aac.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=6128000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,AUDIO="aac"
+http://existing.base/uri/avc_1080_aac.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=128000,CODECS="mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/aac_en.m3u8
+`
+
+	manifestWithoutHighBitrateVideo := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ec3",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/ec3_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2128000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,AUDIO="aac"
+http://existing.base/uri/avc_720_aac.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2384000,CODECS="avc1.64001f,ec-3",RESOLUTION=1280x720,AUDIO="ec3"
+http://existing.base/uri/avc_720_ec3.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=128000,CODECS="mp4a.40.2",AUDIO="aac"
+http://existing.base/uri/aac_en.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=384000,CODECS="ec-3",AUDIO="ec3"
+http://existing.base/uri/ec3_en.m3u8
+`
+
+	manifestWithMuxedAudio := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2128000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/avc_720.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=64000,CODECS="mp4a.40.5"
+http://existing.base/uri/he_aac.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=256000,CODECS="mp4a.40.2"
+http://existing.base/uri/aac.m3u8
+`
+
+	manifestWithoutLowBitrateMuxedAudio := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=2128000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/avc_720.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=256000,CODECS="mp4a.40.2"
+http://existing.base/uri/aac.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when no content bitrate filter is given, expect unfiltered manifest",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithAudioGroups,
+			expectManifestContent: manifestWithAudioGroups,
+		},
+		{
+			name: "when an audio bitrate range is given, expect audio groups whose audio only variant is " +
+				"outside of it to be stripped out along with their variants",
+			filters:               &parsers.MediaFilters{AudioBitrate: &parsers.BitrateRange{Min: 64000, Max: 192000}},
+			manifestContent:       manifestWithAudioGroups,
+			expectManifestContent: manifestWithoutHighBitrateAudio,
+		},
+		{
+			name: "when a video bitrate range is given, expect variants whose bandwidth minus their audio " +
+				"is outside of it to be stripped out and audio only variants to be kept",
+			filters:               &parsers.MediaFilters{VideoBitrate: &parsers.BitrateRange{Min: 0, Max: 2000000}},
+			manifestContent:       manifestWithAudioGroups,
+			expectManifestContent: manifestWithoutHighBitrateVideo,
+		},
+		{
+			name: "when an audio bitrate range is given, expect audio only variants outside of it to be " +
+				"stripped out and variants with muxed audio to be kept",
+			filters:               &parsers.MediaFilters{AudioBitrate: &parsers.BitrateRange{Min: 96000, Max: 320000}},
+			manifestContent:       manifestWithMuxedAudio,
+			expectManifestContent: manifestWithoutLowBitrateMuxedAudio,
+		},
+		{
+			name:            "when an audio bitrate range removes every audio group, expect an error",
+			filters:         &parsers.MediaFilters{AudioBitrate: &parsers.BitrateRange{Min: 500000, Max: 1000000}},
+			manifestContent: manifestWithAudioGroups,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_IframeVariants(t *testing.T) {
+	manifestWithIframes := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/avc_720.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/avc_1080.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=800,AVERAGE-BANDWIDTH=800,CODECS="hvc1.2.4.L93.B0,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/hevc_720.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="hvc1.2.4.L123.B0,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/hevc_1080.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100,CODECS="avc1.64001f",RESOLUTION=1280x720,URI="http://existing.base/uri/avc_720_iframes.m3u8"
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=400,CODECS="avc1.640028",RESOLUTION=1920x1080,URI="http://existing.base/uri/avc_1080_iframes.m3u8"
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=80,CODECS="hvc1.2.4.L93.B0",RESOLUTION=1280x720,URI="http://existing.base/uri/hevc_720_iframes.m3u8"
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=300,RESOLUTION=1920x1080,URI="http://existing.base/uri/1080_iframes.m3u8"
+`
+
+	manifestWithoutHEVC := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/avc_720.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/avc_1080.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100,CODECS="avc1.64001f",RESOLUTION=1280x720,URI="http://existing.base/uri/avc_720_iframes.m3u8"
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=400,CODECS="avc1.640028",RESOLUTION=1920x1080,URI="http://existing.base/uri/avc_1080_iframes.m3u8"
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=300,RESOLUTION=1920x1080,URI="http://existing.base/uri/1080_iframes.m3u8"
+`
+
+	manifestWithoutAVC := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=800,AVERAGE-BANDWIDTH=800,CODECS="hvc1.2.4.L93.B0,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/hevc_720.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="hvc1.2.4.L123.B0,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/hevc_1080.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=80,CODECS="hvc1.2.4.L93.B0",RESOLUTION=1280x720,URI="http://existing.base/uri/hevc_720_iframes.m3u8"
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=300,RESOLUTION=1920x1080,URI="http://existing.base/uri/1080_iframes.m3u8"
+`
+
+	manifestWith1080pOnly := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/avc_1080.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=3000,AVERAGE-BANDWIDTH=3000,CODECS="hvc1.2.4.L123.B0,mp4a.40.2",RESOLUTION=1920x1080
+http://existing.base/uri/hevc_1080.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=400,CODECS="avc1.640028",RESOLUTION=1920x1080,URI="http://existing.base/uri/avc_1080_iframes.m3u8"
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=300,RESOLUTION=1920x1080,URI="http://existing.base/uri/1080_iframes.m3u8"
+`
+
+	manifestWithUnmatchedIframes := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720
+http://existing.base/uri/avc_720.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=40,CODECS="avc1.64001e",RESOLUTION=640x360,URI="http://existing.base/uri/avc_360_iframes.m3u8"
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when no filter is given, expect every i-frame variant to be kept",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithIframes,
+			expectManifestContent: manifestWithIframes,
+		},
+		{
+			name: "when filtering out hevc, expect hevc i-frame variants to be stripped out along with " +
+				"the hevc variants",
+			filters:               &parsers.MediaFilters{Videos: []parsers.VideoType{"hvc"}},
+			manifestContent:       manifestWithIframes,
+			expectManifestContent: manifestWithoutHEVC,
+		},
+		{
+			name: "when filtering out avc, expect i-frame variants without codecs to be kept while a " +
+				"variant of the same resolution is left",
+			filters:               &parsers.MediaFilters{Videos: []parsers.VideoType{"avc"}},
+			manifestContent:       manifestWithIframes,
+			expectManifestContent: manifestWithoutAVC,
+		},
+		{
+			name: "when filtering by bitrate, expect i-frame variants to follow the variants of their " +
+				"resolution rather than their own bandwidth",
+			filters:               &parsers.MediaFilters{MinBitrate: 2000, MaxBitrate: math.MaxInt32},
+			manifestContent:       manifestWithIframes,
+			expectManifestContent: manifestWith1080pOnly,
+		},
+		{
+			name: "when an i-frame variant doesn't belong to any variant, expect it to only go through " +
+				"the filters applied to it directly",
+			filters:               &parsers.MediaFilters{MinBitrate: 500, MaxBitrate: math.MaxInt32},
+			manifestContent:       manifestWithUnmatchedIframes,
+			expectManifestContent: manifestWithUnmatchedIframes,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_PrunesOrphanedGroups(t *testing.T) {
+	manifestWithGroups := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ec3",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/ec3_en.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",DEFAULT=NO,LANGUAGE="en",URI="http://existing.base/uri/subs_en.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs-hd",NAME="English",DEFAULT=NO,LANGUAGE="en",URI="http://existing.base/uri/subs_hd_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",AUDIO="aac",SUBTITLES="subs"
+http://existing.base/uri/link_1.m3u8
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,ec-3",AUDIO="ec3",SUBTITLES="subs-hd"
+http://existing.base/uri/link_2.m3u8
+`
+
+	manifestWithoutEC3 := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/aac_en.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",DEFAULT=NO,LANGUAGE="en",URI="http://existing.base/uri/subs_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=1000,AVERAGE-BANDWIDTH=1000,CODECS="avc1.64001f,mp4a.40.2",AUDIO="aac",SUBTITLES="subs"
+http://existing.base/uri/link_1.m3u8
+`
+
+	manifestWithoutLowBitrate := `#EXTM3U
+#EXT-X-VERSION:4
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ec3",NAME="English",DEFAULT=YES,LANGUAGE="en",URI="http://existing.base/uri/ec3_en.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs-hd",NAME="English",DEFAULT=NO,LANGUAGE="en",URI="http://existing.base/uri/subs_hd_en.m3u8"
+#EXT-X-STREAM-INF:PROGRAM-ID=0,BANDWIDTH=4000,AVERAGE-BANDWIDTH=4000,CODECS="avc1.640028,ec-3",AUDIO="ec3",SUBTITLES="subs-hd"
+http://existing.base/uri/link_2.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+		expectErr             bool
+	}{
+		{
+			name:                  "when no filter is given, expect every group to be kept",
+			filters:               &parsers.MediaFilters{},
+			manifestContent:       manifestWithGroups,
+			expectManifestContent: manifestWithGroups,
+		},
+		{
+			name: "when a codec filter removes every variant of a group, expect the renditions of " +
+				"that group to be stripped out",
+			filters:               &parsers.MediaFilters{Audios: []parsers.AudioType{"ec-3"}},
+			manifestContent:       manifestWithGroups,
+			expectManifestContent: manifestWithoutEC3,
+		},
+		{
+			name: "when a bitrate filter removes every variant of a group, expect the renditions of " +
+				"that group to be stripped out",
+			filters:               &parsers.MediaFilters{MinBitrate: 2000, MaxBitrate: math.MaxInt32},
+			manifestContent:       manifestWithGroups,
+			expectManifestContent: manifestWithoutLowBitrate,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter("", tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+
+			if err != nil && !tt.expectErr {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			} else if err == nil && tt.expectErr {
+				t.Error("FilterManifest() expected an error, got nil")
+				return
+			}
+
+			if g, e := manifest, tt.expectManifestContent; g != e {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", g, e,
+					cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+func TestHLSFilter_FilterManifest_PreservesMasterTags(t *testing.T) {
+	manifestWithAllTags := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-START:TIME-OFFSET=10.5,PRECISE=YES
+#EXT-X-SESSION-DATA:DATA-ID="com.example.title",VALUE="Star Trek",LANGUAGE="en"
+#EXT-X-SESSION-DATA:DATA-ID="com.example.title",VALUE="Jornada nas Estrelas",LANGUAGE="pt-BR"
+#EXT-X-SESSION-DATA:DATA-ID="com.example.lyrics",URI="data/lyrics.json"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI="skd://key-id",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=AES-128,URI="keys/session.key"
+#EXT-CUSTOM-TAG:VALUE="kept"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",LANGUAGE="en",NAME="English",AUTOSELECT=YES,DEFAULT=YES,CHANNELS="2",URI="audio/en.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",LANGUAGE="en",NAME="English",INSTREAM-ID="CC1"
+#EXT-X-STREAM-INF:BANDWIDTH=2000,AVERAGE-BANDWIDTH=1800,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,FRAME-RATE=29.970,AUDIO="aac",CLOSED-CAPTIONS="cc"
+video/720.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5000,AVERAGE-BANDWIDTH=4500,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,FRAME-RATE=29.970,AUDIO="aac",CLOSED-CAPTIONS="cc"
+video/1080.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=300,CODECS="avc1.64001f",RESOLUTION=1280x720,URI="iframe/720.m3u8"
+`
+
+	manifestWithAbsoluteURLs := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-START:TIME-OFFSET=10.5,PRECISE=YES
+#EXT-X-SESSION-DATA:DATA-ID="com.example.title",VALUE="Star Trek",LANGUAGE="en"
+#EXT-X-SESSION-DATA:DATA-ID="com.example.title",VALUE="Jornada nas Estrelas",LANGUAGE="pt-BR"
+#EXT-X-SESSION-DATA:DATA-ID="com.example.lyrics",URI="http://existing.base/uri/data/lyrics.json"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI="skd://key-id",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=AES-128,URI="http://existing.base/uri/keys/session.key"
+#EXT-CUSTOM-TAG:VALUE="kept"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",LANGUAGE="en",NAME="English",AUTOSELECT=YES,DEFAULT=YES,CHANNELS="2",URI="http://existing.base/uri/audio/en.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",LANGUAGE="en",NAME="English",INSTREAM-ID="CC1"
+#EXT-X-STREAM-INF:BANDWIDTH=2000,AVERAGE-BANDWIDTH=1800,CODECS="avc1.64001f,mp4a.40.2",RESOLUTION=1280x720,FRAME-RATE=29.970,AUDIO="aac",CLOSED-CAPTIONS="cc"
+http://existing.base/uri/video/720.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5000,AVERAGE-BANDWIDTH=4500,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,FRAME-RATE=29.970,AUDIO="aac",CLOSED-CAPTIONS="cc"
+http://existing.base/uri/video/1080.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=300,CODECS="avc1.64001f",RESOLUTION=1280x720,URI="http://existing.base/uri/iframe/720.m3u8"
+`
+
+	manifestFilteredBandwidth := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-START:TIME-OFFSET=10.5,PRECISE=YES
+#EXT-X-SESSION-DATA:DATA-ID="com.example.title",VALUE="Star Trek",LANGUAGE="en"
+#EXT-X-SESSION-DATA:DATA-ID="com.example.title",VALUE="Jornada nas Estrelas",LANGUAGE="pt-BR"
+#EXT-X-SESSION-DATA:DATA-ID="com.example.lyrics",URI="http://existing.base/uri/data/lyrics.json"
+#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI="skd://key-id",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-SESSION-KEY:METHOD=AES-128,URI="http://existing.base/uri/keys/session.key"
+#EXT-CUSTOM-TAG:VALUE="kept"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",LANGUAGE="en",NAME="English",AUTOSELECT=YES,DEFAULT=YES,CHANNELS="2",URI="http://existing.base/uri/audio/en.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",LANGUAGE="en",NAME="English",INSTREAM-ID="CC1"
+#EXT-X-STREAM-INF:BANDWIDTH=5000,AVERAGE-BANDWIDTH=4500,CODECS="avc1.640028,mp4a.40.2",RESOLUTION=1920x1080,FRAME-RATE=29.970,AUDIO="aac",CLOSED-CAPTIONS="cc"
+http://existing.base/uri/video/1080.m3u8
+`
+
+	tests := []struct {
+		name                  string
+		manifestURL           string
+		filters               *parsers.MediaFilters
+		manifestContent       string
+		expectManifestContent string
+	}{
+		{
+			name:                  "when no filters are given, expect every tag to round trip",
+			filters:               &parsers.MediaFilters{MinBitrate: 0, MaxBitrate: math.MaxInt32},
+			manifestContent:       manifestWithAbsoluteURLs,
+			expectManifestContent: manifestWithAbsoluteURLs,
+		},
+		{
+			name: "when the manifest has relative uris, expect every tag to round trip with absolute " +
+				"uris",
+			manifestURL:           "http://existing.base/uri/manifest.m3u8",
+			filters:               &parsers.MediaFilters{MinBitrate: 0, MaxBitrate: math.MaxInt32},
+			manifestContent:       manifestWithAllTags,
+			expectManifestContent: manifestWithAbsoluteURLs,
+		},
+		{
+			name: "when variants are filtered, expect session and global tags to be kept along with " +
+				"the remaining variants",
+			manifestURL:           "http://existing.base/uri/manifest.m3u8",
+			filters:               &parsers.MediaFilters{MinBitrate: 3000, MaxBitrate: math.MaxInt32},
+			manifestContent:       manifestWithAllTags,
+			expectManifestContent: manifestFilteredBandwidth,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewHLSFilter(tt.manifestURL, tt.manifestContent, config.Config{})
+			manifest, err := filter.FilterManifest(tt.filters)
+			if err != nil {
+				t.Errorf("FilterManifest() didnt expect an error to be returned, got: %v", err)
+				return
+			}
+
+			if g, e := semanticTags(manifest), semanticTags(tt.expectManifestContent); !cmp.Equal(g, e) {
+				t.Errorf("FilterManifest() wrong manifest returned\ngot %v\nexpected: %v\ndiff: %v", manifest,
+					tt.expectManifestContent, cmp.Diff(g, e))
+			}
+		})
+	}
+}
+
+// semanticTags decodes every line of a playlist so two playlists can be compared
+// regardless of the order of the attributes in each tag
+func semanticTags(manifest string) []map[string]string {
+	var tags []map[string]string
+	for _, line := range strings.Split(manifest, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		tag := map[string]string{"": line}
+		if i := strings.Index(line, ":"); i >= 0 && strings.HasPrefix(line, "#EXT") {
+			tag = m3u8.DecodeAttributeList(line[i+1:])
+			tag[""] = line[:i]
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
@@ -0,0 +1,391 @@
+package filters
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/grafov/m3u8"
+)
+
+// masterPlaylist is a lossless representation of an HLS master playlist. Variants and
+// renditions are exposed as m3u8 types so they can be filtered, while every tag bakery
+// does not filter is written back exactly as it was read from the origin
+type masterPlaylist struct {
+	variants     []*m3u8.Variant
+	alternatives []*m3u8.Alternative
+	tags         []*playlistTag
+}
+
+// playlistTag is a single tag of a playlist along with the URI line following it for
+// EXT-X-STREAM-INF tags
+type playlistTag struct {
+	line        string
+	name        string
+	attributes  attributeList
+	modified    bool
+	uri         string
+	variant     *m3u8.Variant
+	alternative *m3u8.Alternative
+}
+
+// renditionGroup identifies a group of EXT-X-MEDIA renditions by its type and GROUP-ID
+type renditionGroup struct {
+	renditionType string
+	groupID       string
+}
+
+// attribute is a single key/value pair of a tag attribute list
+type attribute struct {
+	key    string
+	value  string
+	quoted bool
+}
+
+// attributeList keeps the attributes of a tag in the order they were declared
+type attributeList []attribute
+
+// decodeMasterPlaylist reads every line of a master playlist into a masterPlaylist
+func decodeMasterPlaylist(content string) *masterPlaylist {
+	p := &masterPlaylist{}
+
+	var streamInf *playlistTag
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if streamInf != nil && !strings.HasPrefix(line, "#") {
+			streamInf.uri = line
+			streamInf.variant.URI = line
+			streamInf = nil
+			continue
+		}
+
+		tag := decodeTag(line)
+		switch tag.name {
+		case "#EXT-X-STREAM-INF":
+			tag.variant = newVariant(tag.attributes, false)
+			p.variants = append(p.variants, tag.variant)
+			streamInf = tag
+		case "#EXT-X-I-FRAME-STREAM-INF":
+			tag.variant = newVariant(tag.attributes, true)
+			p.variants = append(p.variants, tag.variant)
+		case "#EXT-X-MEDIA":
+			tag.alternative = newAlternative(tag.attributes)
+			p.alternatives = append(p.alternatives, tag.alternative)
+		}
+
+		p.tags = append(p.tags, tag)
+	}
+
+	return p
+}
+
+// decodeTag reads a single line of a playlist, along with the attribute list of EXT tags
+func decodeTag(line string) *playlistTag {
+	tag := &playlistTag{line: line, name: line}
+	if i := strings.Index(line, ":"); i >= 0 && strings.HasPrefix(line, "#EXT") {
+		tag.name = line[:i]
+		tag.attributes = decodeAttributeList(line[i+1:])
+	}
+
+	return tag
+}
+
+// renditionGroups returns every group the renditions of the playlist belong to
+func (p *masterPlaylist) renditionGroups() map[renditionGroup]struct{} {
+	groups := map[renditionGroup]struct{}{}
+	for _, a := range p.alternatives {
+		groups[renditionGroup{renditionType: a.Type, groupID: a.GroupId}] = struct{}{}
+	}
+
+	return groups
+}
+
+// alternativeAttribute returns an attribute of the EXT-X-MEDIA tag of a rendition, for the
+// attributes its m3u8 typed view doesn't expose, such as CHANNELS
+func (p *masterPlaylist) alternativeAttribute(a *m3u8.Alternative, key string) (string, bool) {
+	for _, tag := range p.tags {
+		if tag.alternative == a {
+			return tag.attributes.get(key)
+		}
+	}
+
+	return "", false
+}
+
+// insertVariant adds a variant declared by attributes, playing uri, in front of the variant before
+func (p *masterPlaylist) insertVariant(attributes attributeList, uri string, before *m3u8.Variant) {
+	v := newVariant(attributes, false)
+	v.URI = uri
+	tag := &playlistTag{
+		name:       "#EXT-X-STREAM-INF",
+		attributes: attributes,
+		modified:   true,
+		uri:        uri,
+		variant:    v,
+	}
+
+	for i, t := range p.tags {
+		if t.variant == before {
+			p.tags = append(p.tags[:i], append([]*playlistTag{tag}, p.tags[i:]...)...)
+			break
+		}
+	}
+
+	for i, current := range p.variants {
+		if current == before {
+			p.variants = append(p.variants[:i], append([]*m3u8.Variant{v}, p.variants[i:]...)...)
+			break
+		}
+	}
+}
+
+// filterTags removes the tags named name rejected by keep, which is given their attributes
+func (p *masterPlaylist) filterTags(name string, keep func(attributeList) bool) {
+	var tags []*playlistTag
+	for _, tag := range p.tags {
+		if tag.name == name && !keep(tag.attributes) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	p.tags = tags
+}
+
+// String encodes the playlist, leaving out the variants and renditions that were filtered
+func (p *masterPlaylist) String() string {
+	variants := map[*m3u8.Variant]struct{}{}
+	for _, v := range p.variants {
+		variants[v] = struct{}{}
+	}
+
+	alternatives := map[*m3u8.Alternative]struct{}{}
+	for _, a := range p.alternatives {
+		alternatives[a] = struct{}{}
+	}
+
+	var sb strings.Builder
+	for _, tag := range p.tags {
+		if tag.variant != nil {
+			if _, found := variants[tag.variant]; !found {
+				continue
+			}
+			tag.syncVariant()
+		}
+
+		if tag.alternative != nil {
+			if _, found := alternatives[tag.alternative]; !found {
+				continue
+			}
+			tag.syncAlternative()
+		}
+
+		sb.WriteString(tag.String())
+		sb.WriteString("\n")
+		if tag.uri != "" {
+			sb.WriteString(tag.uri)
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// String returns the original line of the tag, unless its attributes were changed
+func (t *playlistTag) String() string {
+	if !t.modified {
+		return t.line
+	}
+
+	return t.name + ":" + t.attributes.String()
+}
+
+// syncVariant writes the variant fields changed by filters back into the attribute list
+func (t *playlistTag) syncVariant() {
+	v := t.variant
+	t.setAttribute("CODECS", v.Codecs, true)
+	t.setAttribute("AUDIO", v.Audio, true)
+	t.setAttribute("VIDEO", v.Video, true)
+	t.setAttribute("SUBTITLES", v.Subtitles, true)
+	// CLOSED-CAPTIONS is an enumerated string when set to NONE
+	t.setAttribute("CLOSED-CAPTIONS", v.Captions, v.Captions != "NONE")
+
+	if v.Iframe {
+		t.setAttribute("URI", v.URI, true)
+		return
+	}
+
+	t.uri = v.URI
+}
+
+// syncAlternative writes the rendition fields changed by filters back into the attribute list
+func (t *playlistTag) syncAlternative() {
+	t.setAttribute("URI", t.alternative.URI, true)
+}
+
+// setAttribute sets the value of an attribute, removing it when the value is empty.
+// Attributes keep their original position and formatting unless their value changed
+func (t *playlistTag) setAttribute(key, value string, quoted bool) {
+	current, found := t.attributes.get(key)
+	switch {
+	case !found && value == "":
+		return
+	case found && current == value:
+		return
+	}
+
+	var attributes attributeList
+	for _, a := range t.attributes {
+		if a.key != key {
+			attributes = append(attributes, a)
+			continue
+		}
+
+		if value != "" {
+			attributes = append(attributes, attribute{key: key, value: value, quoted: quoted})
+		}
+	}
+
+	if !found {
+		attributes = append(attributes, attribute{key: key, value: value, quoted: quoted})
+	}
+
+	t.attributes = attributes
+	t.modified = true
+}
+
+// decodeAttributeList splits an attribute list into its attributes, keeping track of
+// which values were quoted so the list can be encoded back as it was
+func decodeAttributeList(line string) attributeList {
+	var attributes attributeList
+	for len(line) > 0 {
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			break
+		}
+
+		a := attribute{key: strings.TrimSpace(line[:eq])}
+		line = line[eq+1:]
+
+		if strings.HasPrefix(line, `"`) {
+			a.quoted = true
+			end := strings.Index(line[1:], `"`)
+			if end < 0 {
+				a.value, line = line[1:], ""
+			} else {
+				a.value, line = line[1:end+1], line[end+2:]
+			}
+		}
+
+		comma := strings.Index(line, ",")
+		if comma < 0 {
+			comma = len(line)
+		}
+		if !a.quoted {
+			a.value = strings.TrimSpace(line[:comma])
+		}
+
+		line = strings.TrimPrefix(line[comma:], ",")
+		attributes = append(attributes, a)
+	}
+
+	return attributes
+}
+
+func (l attributeList) get(key string) (string, bool) {
+	for _, a := range l {
+		if a.key == key {
+			return a.value, true
+		}
+	}
+
+	return "", false
+}
+
+func (l attributeList) String() string {
+	encoded := make([]string, 0, len(l))
+	for _, a := range l {
+		if a.quoted {
+			encoded = append(encoded, a.key+`="`+a.value+`"`)
+			continue
+		}
+		encoded = append(encoded, a.key+"="+a.value)
+	}
+
+	return strings.Join(encoded, ",")
+}
+
+// newVariant builds the typed view of an EXT-X-STREAM-INF or EXT-X-I-FRAME-STREAM-INF tag
+func newVariant(attributes attributeList, iframe bool) *m3u8.Variant {
+	v := &m3u8.Variant{}
+	v.Iframe = iframe
+	for _, a := range attributes {
+		switch a.key {
+		case "PROGRAM-ID":
+			id, _ := strconv.ParseUint(a.value, 10, 32)
+			v.ProgramId = uint32(id)
+		case "BANDWIDTH":
+			bw, _ := strconv.ParseUint(a.value, 10, 32)
+			v.Bandwidth = uint32(bw)
+		case "AVERAGE-BANDWIDTH":
+			bw, _ := strconv.ParseUint(a.value, 10, 32)
+			v.AverageBandwidth = uint32(bw)
+		case "CODECS":
+			v.Codecs = a.value
+		case "RESOLUTION":
+			v.Resolution = a.value
+		case "AUDIO":
+			v.Audio = a.value
+		case "VIDEO":
+			v.Video = a.value
+		case "SUBTITLES":
+			v.Subtitles = a.value
+		case "CLOSED-CAPTIONS":
+			v.Captions = a.value
+		case "NAME":
+			v.Name = a.value
+		case "FRAME-RATE":
+			v.FrameRate, _ = strconv.ParseFloat(a.value, 64)
+		case "VIDEO-RANGE":
+			v.VideoRange = a.value
+		case "HDCP-LEVEL":
+			v.HDCPLevel = a.value
+		case "URI":
+			v.URI = a.value
+		}
+	}
+
+	return v
+}
+
+// newAlternative builds the typed view of an EXT-X-MEDIA tag
+func newAlternative(attributes attributeList) *m3u8.Alternative {
+	a := &m3u8.Alternative{}
+	for _, attr := range attributes {
+		switch attr.key {
+		case "TYPE":
+			a.Type = attr.value
+		case "GROUP-ID":
+			a.GroupId = attr.value
+		case "LANGUAGE":
+			a.Language = attr.value
+		case "NAME":
+			a.Name = attr.value
+		case "DEFAULT":
+			a.Default = strings.ToUpper(attr.value) == "YES"
+		case "AUTOSELECT":
+			a.Autoselect = attr.value
+		case "FORCED":
+			a.Forced = attr.value
+		case "CHARACTERISTICS":
+			a.Characteristics = attr.value
+		case "URI":
+			a.URI = attr.value
+		}
+	}
+
+	return a
+}
@@ -0,0 +1,324 @@
+package filters
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mediaPlaylistTags are the tags of a media playlist that apply to the whole playlist rather
+// than to the segments following them
+var mediaPlaylistTags = map[string]struct{}{
+	"#EXTM3U":                       {},
+	"#EXT-X-VERSION":                {},
+	"#EXT-X-TARGETDURATION":         {},
+	"#EXT-X-MEDIA-SEQUENCE":         {},
+	"#EXT-X-DISCONTINUITY-SEQUENCE": {},
+	"#EXT-X-PLAYLIST-TYPE":          {},
+	"#EXT-X-I-FRAMES-ONLY":          {},
+	"#EXT-X-INDEPENDENT-SEGMENTS":   {},
+	"#EXT-X-START":                  {},
+	"#EXT-X-ALLOW-CACHE":            {},
+	"#EXT-X-SERVER-CONTROL":         {},
+	"#EXT-X-PART-INF":               {},
+	"#EXT-X-DEFINE":                 {},
+}
+
+// programDateTimeTag associates the first sample of a segment with an absolute date and time,
+// written with a millisecond precision
+const (
+	programDateTimeTag    = "#EXT-X-PROGRAM-DATE-TIME"
+	programDateTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// byteRangeTag declares a segment as a sub-range of the resource of its URI
+const byteRangeTag = "#EXT-X-BYTERANGE"
+
+// carriedSegmentTags are the tags applying to every segment following them until they are
+// declared again, which have to be carried over to the first segment kept when trimming a playlist
+var carriedSegmentTags = []string{"#EXT-X-KEY", "#EXT-X-MAP"}
+
+// mediaPlaylist is a lossless representation of an HLS media playlist. Segments are exposed
+// along with the tags preceding them so they can be filtered, while every tag is written back
+// exactly as it was read from the origin unless a filter changed it
+type mediaPlaylist struct {
+	header   []*playlistTag
+	segments []*mediaSegment
+	footer   []*playlistTag
+}
+
+// mediaSegment is a single segment of a media playlist along with the tags preceding its URI,
+// such as EXTINF, EXT-X-DISCONTINUITY or EXT-X-KEY
+type mediaSegment struct {
+	tags []*playlistTag
+	uri  string
+}
+
+// decodeMediaPlaylist reads every line of a media playlist into a mediaPlaylist. Playlist tags
+// make up its header wherever they are declared, along with the comments and tags preceding them
+// before the first segment, and the tags following the last segment, such as EXT-X-ENDLIST, its
+// footer
+func decodeMediaPlaylist(content string) *mediaPlaylist {
+	p := &mediaPlaylist{}
+
+	var pending []*playlistTag
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, "#") {
+			p.segments = append(p.segments, &mediaSegment{tags: pending, uri: line})
+			pending = nil
+			continue
+		}
+
+		tag := decodeTag(line)
+		if _, found := mediaPlaylistTags[tag.name]; found {
+			// comments and tags read before a playlist tag stay in front of it
+			if len(p.segments) == 0 {
+				p.header = append(p.header, pending...)
+				pending = nil
+			}
+
+			p.header = append(p.header, tag)
+			continue
+		}
+
+		pending = append(pending, tag)
+	}
+	p.footer = pending
+
+	return p
+}
+
+// tags returns every tag of the playlist, in the order they are written
+func (p *mediaPlaylist) tags() []*playlistTag {
+	tags := append([]*playlistTag{}, p.header...)
+	for _, s := range p.segments {
+		tags = append(tags, s.tags...)
+	}
+
+	return append(tags, p.footer...)
+}
+
+// isVOD returns true if no segment will be added to the playlist
+func (p *mediaPlaylist) isVOD() bool {
+	for _, tag := range p.footer {
+		if tag.name == "#EXT-X-ENDLIST" {
+			return true
+		}
+	}
+
+	for _, tag := range p.header {
+		if tag.name == "#EXT-X-PLAYLIST-TYPE" && strings.HasSuffix(tag.line, ":VOD") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// headerValue returns the integer value of a playlist tag, such as EXT-X-MEDIA-SEQUENCE
+func (p *mediaPlaylist) headerValue(name string) (int, bool) {
+	for _, tag := range p.header {
+		if tag.name == name {
+			value, err := strconv.Atoi(strings.TrimPrefix(tag.line, name+":"))
+			return value, err == nil
+		}
+	}
+
+	return 0, false
+}
+
+// setHeaderValue sets the integer value of a playlist tag, adding the tag to the header when
+// it wasn't declared
+func (p *mediaPlaylist) setHeaderValue(name string, value int) {
+	line := name + ":" + strconv.Itoa(value)
+	for _, tag := range p.header {
+		if tag.name == name {
+			tag.line = line
+			return
+		}
+	}
+
+	p.header = append(p.header, &playlistTag{line: line, name: name})
+}
+
+// removeHeaderTag removes a playlist tag from the header
+func (p *mediaPlaylist) removeHeaderTag(name string) {
+	var header []*playlistTag
+	for _, tag := range p.header {
+		if tag.name != name {
+			header = append(header, tag)
+		}
+	}
+
+	p.header = header
+}
+
+// trimSegments keeps the segments from first to last only. Keys and initialization sections of
+// the removed segments still applying to the first segment kept are carried over to it, along
+// with its program date time and byte range offset, and the media and discontinuity sequence
+// numbers are moved past the removed segments
+func (p *mediaPlaylist) trimSegments(first, last int) {
+	if first > 0 {
+		kept := p.segments[first]
+		for _, name := range carriedSegmentTags {
+			if kept.hasTag(name) {
+				continue
+			}
+
+			for i := first - 1; i >= 0; i-- {
+				if carried := p.segments[i].tagsNamed(name); len(carried) > 0 {
+					kept.tags = append(carried, kept.tags...)
+					break
+				}
+			}
+		}
+
+		p.carryProgramDateTime(first)
+		p.carryByteRangeOffset(first)
+
+		discontinuities := 0
+		for _, s := range p.segments[:first] {
+			discontinuities += len(s.tagsNamed("#EXT-X-DISCONTINUITY"))
+		}
+
+		mediaSequence, _ := p.headerValue("#EXT-X-MEDIA-SEQUENCE")
+		p.setHeaderValue("#EXT-X-MEDIA-SEQUENCE", mediaSequence+first)
+		if discontinuities > 0 {
+			discontinuitySequence, _ := p.headerValue("#EXT-X-DISCONTINUITY-SEQUENCE")
+			p.setHeaderValue("#EXT-X-DISCONTINUITY-SEQUENCE", discontinuitySequence+discontinuities)
+		}
+	}
+
+	p.segments = p.segments[first : last+1]
+}
+
+// carryProgramDateTime gives the segment at index first the date and time of its first sample
+// when it doesn't declare one, from the last EXT-X-PROGRAM-DATE-TIME of the segments before it
+// moved forward by the duration of the segments in between
+func (p *mediaPlaylist) carryProgramDateTime(first int) {
+	kept := p.segments[first]
+	if kept.hasTag(programDateTimeTag) {
+		return
+	}
+
+	var elapsed float64
+	for i := first - 1; i >= 0; i-- {
+		elapsed += p.segments[i].duration()
+
+		tags := p.segments[i].tagsNamed(programDateTimeTag)
+		if len(tags) == 0 {
+			continue
+		}
+
+		dateTime, err := time.Parse(time.RFC3339Nano, strings.TrimPrefix(tags[len(tags)-1].line, programDateTimeTag+":"))
+		if err != nil {
+			return
+		}
+
+		dateTime = dateTime.Add(time.Duration(math.Round(elapsed*1000)) * time.Millisecond)
+		kept.insertTag(&playlistTag{
+			line: programDateTimeTag + ":" + dateTime.Format(programDateTimeFormat),
+			name: programDateTimeTag,
+		})
+		return
+	}
+}
+
+// carryByteRangeOffset writes the offset of the sub-range of the segment at index first when it
+// is implied by the sub-range of the segment before it, which starts where the previous one ends
+func (p *mediaPlaylist) carryByteRangeOffset(first int) {
+	var end int64
+	for i, s := range p.segments[:first+1] {
+		tags := s.tagsNamed(byteRangeTag)
+		if len(tags) == 0 {
+			continue
+		}
+
+		tag := tags[len(tags)-1]
+		byteRange := strings.SplitN(strings.TrimPrefix(tag.line, byteRangeTag+":"), "@", 2)
+		length, err := strconv.ParseInt(byteRange[0], 10, 64)
+		if err != nil {
+			return
+		}
+
+		offset := end
+		if len(byteRange) == 2 {
+			if offset, err = strconv.ParseInt(byteRange[1], 10, 64); err != nil {
+				return
+			}
+		} else if i == first {
+			tag.line = byteRangeTag + ":" + strconv.FormatInt(length, 10) + "@" + strconv.FormatInt(offset, 10)
+		}
+
+		end = offset + length
+	}
+}
+
+// insertTag adds a tag to the segment, in front of its EXTINF tag
+func (s *mediaSegment) insertTag(tag *playlistTag) {
+	for i, t := range s.tags {
+		if t.name == "#EXTINF" {
+			s.tags = append(s.tags[:i], append([]*playlistTag{tag}, s.tags[i:]...)...)
+			return
+		}
+	}
+
+	s.tags = append(s.tags, tag)
+}
+
+// duration returns the duration of the segment in seconds, as declared by its EXTINF tag
+func (s *mediaSegment) duration() float64 {
+	for _, tag := range s.tagsNamed("#EXTINF") {
+		value := strings.TrimPrefix(tag.line, "#EXTINF:")
+		if i := strings.Index(value, ","); i >= 0 {
+			value = value[:i]
+		}
+
+		duration, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return duration
+	}
+
+	return 0
+}
+
+func (s *mediaSegment) hasTag(name string) bool {
+	return len(s.tagsNamed(name)) > 0
+}
+
+func (s *mediaSegment) tagsNamed(name string) []*playlistTag {
+	var tags []*playlistTag
+	for _, tag := range s.tags {
+		if tag.name == name {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// String encodes the playlist
+func (p *mediaPlaylist) String() string {
+	var sb strings.Builder
+	writeTags := func(tags []*playlistTag) {
+		for _, tag := range tags {
+			sb.WriteString(tag.String())
+			sb.WriteString("\n")
+		}
+	}
+
+	writeTags(p.header)
+	for _, s := range p.segments {
+		writeTags(s.tags)
+		sb.WriteString(s.uri)
+		sb.WriteString("\n")
+	}
+	writeTags(p.footer)
+
+	return sb.String()
+}
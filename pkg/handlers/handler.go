@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/cbsinteractive/bakery/pkg/config"
 	"github.com/cbsinteractive/bakery/pkg/filters"
@@ -24,10 +26,20 @@ func LoadHandler(c config.Config) http.Handler {
 		// parse all the filters from the URL
 		masterManifestPath, mediaFilters, err := parsers.URLParse(r.URL.Path)
 		if err != nil {
-			httpError(c, w, err, "failed parsing url", http.StatusInternalServerError)
+			code := http.StatusInternalServerError
+			var filterErr *parsers.FilterError
+			if errors.As(err, &filterErr) {
+				code = http.StatusBadRequest
+			}
+
+			httpError(c, w, err, "failed parsing url", code)
 			return
 		}
 
+		for alias, expanded := range mediaFilters.CodecAliases {
+			logger.Debugf("codec alias %s expanded to %s", alias, strings.Join(expanded, ","))
+		}
+
 		//configure origin from path
 		manifestOrigin, err := origin.Configure(c, masterManifestPath)
 		if err != nil {
@@ -61,7 +73,13 @@ func LoadHandler(c config.Config) http.Handler {
 		// apply the filters to the origin manifest
 		filteredManifest, err := f.FilterManifest(mediaFilters)
 		if err != nil {
-			httpError(c, w, err, "failed to filter manifest", http.StatusInternalServerError)
+			code := http.StatusInternalServerError
+			var filterErr *parsers.FilterError
+			if errors.As(err, &filterErr) {
+				code = http.StatusBadRequest
+			}
+
+			httpError(c, w, err, "failed to filter manifest", code)
 			return
 		}
 
@@ -91,6 +109,6 @@ func fetchManifest(c config.Config, manifestURL string) (string, error) {
 
 func httpError(c config.Config, w http.ResponseWriter, err error, message string, code int) {
 	logger := c.GetLogger()
-	logger.WithError(err).Infof(message)
+	logger.WithError(err).Info(message)
 	http.Error(w, message+": "+err.Error(), code)
 }
@@ -1,11 +1,16 @@
 package parsers
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"path"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/cbsinteractive/bakery/pkg/codecs"
 )
 
 // VideoType is the video codec we need in a given playlist
@@ -26,9 +31,30 @@ type CaptionType string
 // StreamType represents one stream type (e.g. video, audio, text)
 type StreamType string
 
+// AudioDescription tells whether audio tracks describing the video for the visually impaired
+// are removed from a playlist or are the only ones kept in it
+type AudioDescription string
+
+// ClosedCaption is a closed captions channel, given either by its INSTREAM-ID (e.g. CC1 or
+// SERVICE1) or by its language
+type ClosedCaption string
+
+// Role is a DASH role of the urn:mpeg:dash:role:2011 scheme, e.g. main or commentary
+type Role string
+
+// DRMSystem is a DRM system whose keys are kept in a playlist, e.g. widevine
+type DRMSystem string
+
+// DynamicRange is the dynamic range video streams can be played back in
+type DynamicRange string
+
 // Protocol describe the valid protocols
 type Protocol string
 
+// FilterMode tells whether the values of a codec filter are removed from the manifest
+// or are the only ones kept in it
+type FilterMode string
+
 const (
 	videoHDR10       VideoType = "hdr10"
 	videoDolbyVision VideoType = "dovi"
@@ -44,35 +70,136 @@ const (
 	audioLangES   AudioLanguage = "es-MX"
 	audioLangEN   AudioLanguage = "en"
 
+	streamVideo StreamType = "video"
+	streamAudio StreamType = "audio"
+	streamText  StreamType = "text"
+	streamImage StreamType = "image"
+
 	captionPTBR CaptionLanguage = "pt-BR"
 	captionES   CaptionLanguage = "es-MX"
 	captionEN   CaptionLanguage = "en"
 
+	// AudioDescriptionExclude removes audio description tracks
+	AudioDescriptionExclude AudioDescription = "exclude"
+	// AudioDescriptionOnly keeps audio description tracks only
+	AudioDescriptionOnly AudioDescription = "only"
+
+	// DynamicRangeSDR is standard dynamic range video
+	DynamicRangeSDR DynamicRange = "sdr"
+	// DynamicRangePQ is HDR video using the PQ transfer function, such as HDR10
+	DynamicRangePQ DynamicRange = "pq"
+	// DynamicRangeHLG is HDR video using the HLG transfer function
+	DynamicRangeHLG DynamicRange = "hlg"
+	// DynamicRangeDolbyVision is Dolby Vision video
+	DynamicRangeDolbyVision DynamicRange = "dovi"
+
+	// DRMSystemWidevine is Google Widevine
+	DRMSystemWidevine DRMSystem = "widevine"
+	// DRMSystemPlayReady is Microsoft PlayReady
+	DRMSystemPlayReady DRMSystem = "playready"
+	// DRMSystemFairPlay is Apple FairPlay Streaming
+	DRMSystemFairPlay DRMSystem = "fairplay"
+
+	// FilterModeExclude removes the codecs given in a filter
+	FilterModeExclude FilterMode = ""
+	// FilterModeInclude keeps only the codecs given in a filter, e.g. v(+avc)
+	FilterModeInclude FilterMode = "include"
+
 	// ProtocolHLS for manifest in hls
 	ProtocolHLS Protocol = "hls"
 	// ProtocolDASH for manifests in dash
 	ProtocolDASH Protocol = "dash"
 )
 
+// BitrateRange is an inclusive range of bitrates, in bps, for a single content type
+type BitrateRange struct {
+	Min int
+	Max int
+}
+
+// TimeRange is a range of time from the start of a VOD presentation
+type TimeRange struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// FilterError is returned when a filter given in the url is unknown or has an invalid value
+type FilterError struct {
+	Key    string
+	Value  string
+	Reason string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("invalid filter %s(%s): %s", e.Key, e.Value, e.Reason)
+}
+
 // MediaFilters is a struct that carry all the information passed via url
 type MediaFilters struct {
-	Videos            []VideoType       `json:",omitempty"`
-	Audios            []AudioType       `json:",omitempty"`
-	AudioLanguages    []AudioLanguage   `json:",omitempty"`
-	CaptionLanguages  []CaptionLanguage `json:",omitempty"`
-	CaptionTypes      []CaptionType     `json:",omitempty"`
-	FilterStreamTypes []StreamType      `json:",omitempty"`
-	MaxBitrate        int               `json:",omitempty"`
-	MinBitrate        int               `json:",omitempty"`
-	Protocol          Protocol          `json:"protocol"`
+	Videos            []VideoType                `json:",omitempty"`
+	VideoMode         FilterMode                 `json:",omitempty"`
+	Audios            []AudioType                `json:",omitempty"`
+	AudioMode         FilterMode                 `json:",omitempty"`
+	AudioLanguages    []AudioLanguage            `json:",omitempty"`
+	CaptionLanguages  []CaptionLanguage          `json:",omitempty"`
+	CaptionTypes      []CaptionType              `json:",omitempty"`
+	CaptionTypeMode   FilterMode                 `json:",omitempty"`
+	FilterStreamTypes []StreamType               `json:",omitempty"`
+	MaxBitrate        int                        `json:",omitempty"`
+	MinBitrate        int                        `json:",omitempty"`
+	VideoBitrate      *BitrateRange              `json:",omitempty"`
+	AudioBitrate      *BitrateRange              `json:",omitempty"`
+	MaxHeight         int                        `json:",omitempty"`
+	MinHeight         int                        `json:",omitempty"`
+	MaxFrameRate      float64                    `json:",omitempty"`
+	DynamicRanges     []DynamicRange             `json:",omitempty"`
+	DynamicRangeMode  FilterMode                 `json:",omitempty"`
+	MaxAudioChannels  int                        `json:",omitempty"`
+	ExcludeJOC        bool                       `json:",omitempty"`
+	AudioDescription  AudioDescription           `json:",omitempty"`
+	Roles             []Role                     `json:",omitempty"`
+	RoleMode          FilterMode                 `json:",omitempty"`
+	ClosedCaptions    []ClosedCaption            `json:",omitempty"`
+	ClosedCaptionMode FilterMode                 `json:",omitempty"`
+	DRMSystems        []DRMSystem                `json:",omitempty"`
+	Clip              *TimeRange                 `json:",omitempty"`
+	DVRWindow         time.Duration              `json:",omitempty"`
+	VideoLevels       map[codecs.Family]float64  `json:",omitempty"`
+	VideoProfiles     map[codecs.Family][]string `json:",omitempty"`
+	CodecAliases      map[string][]string        `json:",omitempty"`
+	Protocol          Protocol                   `json:"protocol"`
+	// FilterPath is the part of the url path declaring the filters, e.g. /a(ec-3)/b(0,5000)
+	FilterPath string `json:"-"`
+}
+
+// roles are the values of the urn:mpeg:dash:role:2011 scheme
+var roles = map[Role]struct{}{
+	"main":                           {},
+	"alternate":                      {},
+	"supplementary":                  {},
+	"commentary":                     {},
+	"dub":                            {},
+	"emergency":                      {},
+	"caption":                        {},
+	"subtitle":                       {},
+	"sign":                           {},
+	"description":                    {},
+	"enhanced-audio-intelligibility": {},
+	"forced-subtitle":                {},
+	"easyreader":                     {},
+	"karaoke":                        {},
+	"metadata":                       {},
 }
 
-var urlParseRegexp = regexp.MustCompile(`(.*)\((.*)\)`)
+var urlParseRegexp = regexp.MustCompile(`^(\w+)\((.*)\)$`)
 
 // URLParse will generate a MediaFilters struct with
 // all the filters that needs to be applied to the
 // master manifest. It will also return the master manifest
-// url without the filters.
+// url without the filters. Codec aliases such as hevc or
+// eac3 are expanded to the codecs they stand for. A
+// *FilterError is returned when a filter is unknown or
+// has an invalid value.
 func URLParse(urlpath string) (string, *MediaFilters, error) {
 	mf := new(MediaFilters)
 	parts := strings.Split(urlpath, "/")
@@ -100,20 +227,72 @@ func URLParse(urlpath string) (string, *MediaFilters, error) {
 			continue
 		}
 
-		filters := strings.Split(subparts[2], ",")
+		mf.FilterPath += "/" + part
+		key, value := subparts[1], subparts[2]
+		filters := strings.Split(value, ",")
 
-		switch key := subparts[1]; key {
-		case "v":
-			for _, videoType := range filters {
-				if videoType == "hdr10" {
-					mf.Videos = append(mf.Videos, VideoType("hev1.2"), VideoType("hvc1.2"))
-					continue
+		switch key {
+		case "v", "a", "al", "c", "ct", "fs", "hdr", "ch", "role", "cc", "drm":
+			for _, filter := range filters {
+				if filter == "" {
+					return "", nil, &FilterError{Key: key, Value: value, Reason: "empty value"}
 				}
+			}
+		case "vl", "vp":
+			for _, filter := range filters {
+				if !strings.Contains(filter, ":") {
+					return "", nil, &FilterError{Key: key, Value: filter, Reason: "expected a codec:value pair"}
+				}
+			}
+		case "b", "r":
+			if len(filters) > 2 {
+				return "", nil, &FilterError{Key: key, Value: value, Reason: "expected a range of at most two values"}
+			}
 
+			bounds := value
+			if i := strings.Index(bounds, ":"); key == "b" && i >= 0 {
+				bounds = bounds[i+1:]
+			}
+			if strings.Trim(bounds, ",") == "" {
+				return "", nil, &FilterError{Key: key, Value: value, Reason: "empty range"}
+			}
+		case "fr", "ad", "dvr":
+			if len(filters) != 1 {
+				return "", nil, &FilterError{Key: key, Value: value, Reason: "expected a single value"}
+			}
+		case "t":
+			if len(filters) != 2 {
+				return "", nil, &FilterError{Key: key, Value: value, Reason: "expected a start and an end"}
+			}
+		default:
+			return "", nil, &FilterError{Key: key, Value: value, Reason: "unknown filter"}
+		}
+
+		switch key {
+		case "v":
+			if err := parseFilterMode(key, filters, &mf.VideoMode, len(mf.Videos) > 0); err != nil {
+				return "", nil, err
+			}
+
+			for _, videoType := range mf.expandCodecAliases(filters) {
 				mf.Videos = append(mf.Videos, VideoType(videoType))
 			}
 		case "a":
-			for _, audioType := range filters {
+			if err := parseFilterMode(key, filters, &mf.AudioMode, len(mf.Audios) > 0); err != nil {
+				return "", nil, err
+			}
+
+			for _, audioType := range mf.expandCodecAliases(filters) {
+				// a(noAd) is a shorthand for ad(exclude)
+				if AudioType(audioType) == audioNoAudioDescription {
+					if mf.AudioMode == FilterModeInclude {
+						return "", nil, &FilterError{Key: key, Value: value, Reason: "noAd can't be included"}
+					}
+
+					mf.AudioDescription = AudioDescriptionExclude
+					continue
+				}
+
 				mf.Audios = append(mf.Audios, AudioType(audioType))
 			}
 		case "al":
@@ -125,34 +304,385 @@ func URLParse(urlpath string) (string, *MediaFilters, error) {
 				mf.CaptionLanguages = append(mf.CaptionLanguages, CaptionLanguage(captionLanguage))
 			}
 		case "ct":
+			if err := parseFilterMode(key, filters, &mf.CaptionTypeMode, len(mf.CaptionTypes) > 0); err != nil {
+				return "", nil, err
+			}
+
 			if mf.CaptionTypes == nil {
 				mf.CaptionTypes = []CaptionType{}
 			}
 
-			for _, captionType := range filters {
+			for _, captionType := range mf.expandCodecAliases(filters) {
 				mf.CaptionTypes = append(mf.CaptionTypes, CaptionType(captionType))
 			}
 		case "fs":
 			for _, streamType := range filters {
+				switch StreamType(streamType) {
+				case streamVideo, streamAudio, streamText, streamImage:
+				default:
+					return "", nil, &FilterError{Key: key, Value: streamType, Reason: "unknown stream type"}
+				}
+
 				mf.FilterStreamTypes = append(mf.FilterStreamTypes, StreamType(streamType))
 			}
+		case "hdr":
+			if err := parseFilterMode(key, filters, &mf.DynamicRangeMode, len(mf.DynamicRanges) > 0); err != nil {
+				return "", nil, err
+			}
+
+			for _, dynamicRange := range filters {
+				switch DynamicRange(strings.ToLower(dynamicRange)) {
+				case DynamicRangeSDR, DynamicRangePQ, DynamicRangeHLG, DynamicRangeDolbyVision:
+				default:
+					return "", nil, &FilterError{Key: key, Value: dynamicRange, Reason: "unknown dynamic range"}
+				}
+
+				mf.DynamicRanges = append(mf.DynamicRanges, DynamicRange(strings.ToLower(dynamicRange)))
+			}
+		case "cc":
+			if err := parseFilterMode(key, filters, &mf.ClosedCaptionMode, len(mf.ClosedCaptions) > 0); err != nil {
+				return "", nil, err
+			}
+
+			for _, closedCaption := range filters {
+				mf.ClosedCaptions = append(mf.ClosedCaptions, ClosedCaption(closedCaption))
+			}
+		case "role":
+			if err := parseFilterMode(key, filters, &mf.RoleMode, len(mf.Roles) > 0); err != nil {
+				return "", nil, err
+			}
+
+			for _, role := range filters {
+				if _, found := roles[Role(strings.ToLower(role))]; !found {
+					return "", nil, &FilterError{Key: key, Value: role, Reason: "unknown role"}
+				}
+
+				mf.Roles = append(mf.Roles, Role(strings.ToLower(role)))
+			}
+		case "drm":
+			for _, system := range filters {
+				switch DRMSystem(strings.ToLower(system)) {
+				case DRMSystemWidevine, DRMSystemPlayReady, DRMSystemFairPlay:
+				default:
+					return "", nil, &FilterError{Key: key, Value: system, Reason: "unknown drm system"}
+				}
+
+				mf.DRMSystems = append(mf.DRMSystems, DRMSystem(strings.ToLower(system)))
+			}
+		case "ch":
+			for _, channels := range filters {
+				switch strings.ToLower(channels) {
+				case "stereo":
+					mf.MaxAudioChannels = 2
+				case "nojoc":
+					mf.ExcludeJOC = true
+				default:
+					maxChannels, err := strconv.Atoi(channels)
+					if err != nil || maxChannels <= 0 {
+						return "", nil, &FilterError{Key: key, Value: channels, Reason: "must be a positive integer, stereo or nojoc"}
+					}
+
+					mf.MaxAudioChannels = maxChannels
+				}
+			}
 		case "b":
-			if filters[0] != "" {
-				mf.MinBitrate, _ = strconv.Atoi(filters[0])
+			// a range can be scoped to a content type, e.g. b(video:500,5000), and is given in Kbps
+			if i := strings.Index(filters[0], ":"); i >= 0 {
+				filters[0] = filters[0][i+1:]
+				bitrate, err := parseBitrateRange(key, filters)
+				if err != nil {
+					// report the range as it was given, along with its content type
+					var filterErr *FilterError
+					if errors.As(err, &filterErr) {
+						filterErr.Value = value
+					}
+					return "", nil, err
+				}
+
+				switch contentType := StreamType(value[:i]); contentType {
+				case streamVideo:
+					mf.VideoBitrate = bitrate
+				case streamAudio:
+					mf.AudioBitrate = bitrate
+				default:
+					return "", nil, &FilterError{Key: key, Value: value, Reason: "bitrate ranges can only be scoped to video or audio"}
+				}
+				break
+			}
+
+			if err := parseRange(key, filters, &mf.MinBitrate, &mf.MaxBitrate); err != nil {
+				return "", nil, err
+			}
+		case "fr":
+			frameRate, err := parseNumber(filters[0])
+			if err != nil || frameRate <= 0 {
+				return "", nil, &FilterError{Key: key, Value: filters[0], Reason: "must be a positive number"}
+			}
+
+			mf.MaxFrameRate = frameRate
+		case "ad":
+			switch audioDescription := AudioDescription(strings.ToLower(filters[0])); audioDescription {
+			case AudioDescriptionExclude, AudioDescriptionOnly:
+				mf.AudioDescription = audioDescription
+			default:
+				return "", nil, &FilterError{Key: key, Value: filters[0], Reason: "must be exclude or only"}
+			}
+		case "vl":
+			for _, filter := range filters {
+				family, level, err := parseCodecPair(key, filter)
+				if err != nil {
+					return "", nil, err
+				}
+
+				maxLevel, err := parseNumber(level)
+				if err != nil || maxLevel <= 0 {
+					return "", nil, &FilterError{Key: key, Value: filter, Reason: "level must be a positive number"}
+				}
+
+				if mf.VideoLevels == nil {
+					mf.VideoLevels = map[codecs.Family]float64{}
+				}
+				mf.VideoLevels[family] = maxLevel
+			}
+		case "vp":
+			for _, filter := range filters {
+				family, profile, err := parseCodecPair(key, filter)
+				if err != nil {
+					return "", nil, err
+				}
+
+				if mf.VideoProfiles == nil {
+					mf.VideoProfiles = map[codecs.Family][]string{}
+				}
+				mf.VideoProfiles[family] = append(mf.VideoProfiles[family], strings.ToLower(profile))
+			}
+		case "r":
+			if err := parseRange(key, filters, &mf.MinHeight, &mf.MaxHeight); err != nil {
+				return "", nil, err
+			}
+		case "dvr":
+			seconds, err := parseNumber(filters[0])
+			if err != nil || seconds <= 0 {
+				return "", nil, &FilterError{Key: key, Value: filters[0], Reason: "must be a positive number of seconds"}
+			}
+
+			window, err := secondsToDuration(key, filters[0], seconds)
+			if err != nil {
+				return "", nil, err
 			}
 
-			if filters[1] != "" {
-				mf.MaxBitrate, _ = strconv.Atoi(filters[1])
+			mf.DVRWindow = window
+		case "t":
+			clip, err := parseTimeRange(key, filters)
+			if err != nil {
+				return "", nil, err
 			}
+
+			mf.Clip = clip
 		}
 	}
 
 	return masterManifestPath, mf, nil
 }
 
-//DefinesBitrateFilter will check if bitrate filter is set
+// parseFilterMode strips the "+" prefix of include mode values from a codec filter and sets
+// mode accordingly. Every value of a content type has to use the same mode, including the
+// values given by a previous filter with the same key when defined is true
+func parseFilterMode(key string, values []string, mode *FilterMode, defined bool) error {
+	original := strings.Join(values, ",")
+	valuesMode := FilterModeExclude
+	if strings.HasPrefix(values[0], "+") {
+		valuesMode = FilterModeInclude
+	}
+
+	for i, value := range values {
+		if strings.HasPrefix(value, "+") != (valuesMode == FilterModeInclude) {
+			return &FilterError{Key: key, Value: original, Reason: "include (+) and exclude values can't be mixed"}
+		}
+
+		values[i] = strings.TrimPrefix(value, "+")
+		if values[i] == "" {
+			return &FilterError{Key: key, Value: value, Reason: "empty value"}
+		}
+	}
+
+	if defined && valuesMode != *mode {
+		return &FilterError{Key: key, Value: original, Reason: "include (+) and exclude values can't be mixed"}
+	}
+
+	*mode = valuesMode
+
+	return nil
+}
+
+// expandCodecAliases replaces the codec aliases in values with the codecs they stand for,
+// keeping track of each expansion in CodecAliases
+func (f *MediaFilters) expandCodecAliases(values []string) []string {
+	var expanded []string
+	for _, value := range values {
+		codecValues, aliased := codecs.Expand(value)
+		if aliased {
+			if f.CodecAliases == nil {
+				f.CodecAliases = map[string][]string{}
+			}
+			f.CodecAliases[value] = codecValues
+		}
+
+		expanded = append(expanded, codecValues...)
+	}
+
+	return expanded
+}
+
+// parseCodecPair splits a codec:value pair, returning the codec family it refers to
+func parseCodecPair(key, pair string) (codecs.Family, string, error) {
+	i := strings.Index(pair, ":")
+	family, found := codecs.ParseFamily(pair[:i])
+	if !found {
+		return "", "", &FilterError{Key: key, Value: pair, Reason: "unknown codec"}
+	}
+
+	if pair[i+1:] == "" {
+		return "", "", &FilterError{Key: key, Value: pair, Reason: "empty value"}
+	}
+
+	return family, pair[i+1:], nil
+}
+
+// parseNumber parses a finite number, rejecting the NaN and infinite values ParseFloat accepts
+func parseNumber(value string) (float64, error) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err == nil && (math.IsNaN(n) || math.IsInf(n, 0)) {
+		return 0, strconv.ErrSyntax
+	}
+
+	return n, err
+}
+
+// secondsToDuration converts a number of seconds given as value to a time.Duration, rejecting
+// the values it can't hold
+func secondsToDuration(key, value string, seconds float64) (time.Duration, error) {
+	nanoseconds := seconds * float64(time.Second)
+	if nanoseconds >= math.MaxInt64 {
+		return 0, &FilterError{Key: key, Value: value, Reason: "exceeds the longest supported duration"}
+	}
+
+	return time.Duration(nanoseconds), nil
+}
+
+// parseBitrateRange parses a range of bitrates given in Kbps, and returns it in bps, the unit of
+// the HLS BANDWIDTH attribute and of the DASH bandwidth attribute
+func parseBitrateRange(key string, values []string) (*BitrateRange, error) {
+	bitrate := &BitrateRange{Max: math.MaxInt32}
+	if err := parseRange(key, values, &bitrate.Min, &bitrate.Max); err != nil {
+		return nil, err
+	}
+
+	bitrate.Min = kbpsToBps(bitrate.Min)
+	if bitrate.Max != math.MaxInt32 {
+		bitrate.Max = kbpsToBps(bitrate.Max)
+	}
+
+	return bitrate, nil
+}
+
+// kbpsToBps converts a bitrate from Kbps to bps, capping it to the largest bitrate supported
+func kbpsToBps(kbps int) int {
+	if kbps > math.MaxInt32/1000 {
+		return math.MaxInt32
+	}
+
+	return kbps * 1000
+}
+
+// parseRange reads the (min,max) values of a range filter into min and max, leaving
+// them untouched when a value is omitted, and rejects a minimum greater than the maximum
+func parseRange(key string, values []string, min, max *int) error {
+	for i, bound := range []*int{min, max} {
+		if i >= len(values) || values[i] == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(values[i])
+		if err != nil || v < 0 {
+			return &FilterError{Key: key, Value: values[i], Reason: "must be a non-negative integer"}
+		}
+
+		*bound = v
+	}
+
+	if len(values) == 2 && values[0] != "" && values[1] != "" && *min > *max {
+		return &FilterError{Key: key, Value: strings.Join(values, ","), Reason: "minimum is greater than maximum"}
+	}
+
+	return nil
+}
+
+// parseTimeRange reads the (start,end) values of a time range given in seconds, e.g. t(30,90.5)
+func parseTimeRange(key string, values []string) (*TimeRange, error) {
+	var bounds [2]time.Duration
+	for i, value := range values {
+		seconds, err := parseNumber(value)
+		if err != nil || seconds < 0 {
+			return nil, &FilterError{Key: key, Value: value, Reason: "must be a non-negative number of seconds"}
+		}
+
+		if bounds[i], err = secondsToDuration(key, value, seconds); err != nil {
+			return nil, err
+		}
+	}
+
+	if bounds[1] <= bounds[0] {
+		return nil, &FilterError{Key: key, Value: strings.Join(values, ","), Reason: "end must be after start"}
+	}
+
+	return &TimeRange{Start: bounds[0], End: bounds[1]}, nil
+}
+
+// DefinesBitrateFilter will check if bitrate filter is set
 func (f *MediaFilters) DefinesBitrateFilter() bool {
 	return (f.MinBitrate >= 0 && f.MaxBitrate <= math.MaxInt32) &&
 		(f.MinBitrate < f.MaxBitrate) &&
 		!(f.MinBitrate == 0 && f.MaxBitrate == math.MaxInt32)
 }
+
+// DefinesResolutionFilter will check if resolution filter is set
+func (f *MediaFilters) DefinesResolutionFilter() bool {
+	return f.MinHeight > 0 || f.MaxHeight > 0
+}
+
+// DefinesContentBitrateFilter will check if a video or audio bitrate range is set
+func (f *MediaFilters) DefinesContentBitrateFilter() bool {
+	return f.VideoBitrate != nil || f.AudioBitrate != nil
+}
+
+// Contains will check if a bitrate is within the range
+func (r *BitrateRange) Contains(bitrate int) bool {
+	return bitrate >= r.Min && bitrate <= r.Max
+}
+
+// DefinesCodecCapsFilter will check if video profile or level caps are set
+func (f *MediaFilters) DefinesCodecCapsFilter() bool {
+	return len(f.VideoLevels) > 0 || len(f.VideoProfiles) > 0
+}
+
+// DefinesDynamicRangeFilter will check if dynamic range filter is set
+func (f *MediaFilters) DefinesDynamicRangeFilter() bool {
+	return len(f.DynamicRanges) > 0
+}
+
+// DefinesAudioChannelsFilter will check if audio channels filter is set
+func (f *MediaFilters) DefinesAudioChannelsFilter() bool {
+	return f.MaxAudioChannels > 0 || f.ExcludeJOC
+}
+
+// DefinesFrameRateFilter will check if frame rate filter is set
+func (f *MediaFilters) DefinesFrameRateFilter() bool {
+	return f.MaxFrameRate > 0
+}
+
+// ValidHeight will check if a height is within the resolution filter range. A MaxHeight of 0
+// leaves the range unbounded
+func (f *MediaFilters) ValidHeight(height int) bool {
+	return height >= f.MinHeight && (f.MaxHeight == 0 || height <= f.MaxHeight)
+}
@@ -2,9 +2,13 @@ package parsers
 
 import (
 	"encoding/json"
+	"errors"
 	"math"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/cbsinteractive/bakery/pkg/codecs"
 )
 
 func TestURLParseUrl(t *testing.T) {
@@ -18,9 +22,10 @@ func TestURLParseUrl(t *testing.T) {
 			"one video type",
 			"/v(hdr10)/",
 			MediaFilters{
-				Videos:     []VideoType{"hev1.2", "hvc1.2"},
-				MaxBitrate: math.MaxInt32,
-				MinBitrate: 0,
+				Videos:       []VideoType{"hev1.2", "hvc1.2"},
+				CodecAliases: map[string][]string{"hdr10": {"hev1.2", "hvc1.2"}},
+				MaxBitrate:   math.MaxInt32,
+				MinBitrate:   0,
 			},
 			"/",
 		},
@@ -28,9 +33,10 @@ func TestURLParseUrl(t *testing.T) {
 			"two video types",
 			"/v(hdr10,hevc)/",
 			MediaFilters{
-				Videos:     []VideoType{"hev1.2", "hvc1.2", videoHEVC},
-				MaxBitrate: math.MaxInt32,
-				MinBitrate: 0,
+				Videos:       []VideoType{"hev1.2", "hvc1.2", "hvc"},
+				CodecAliases: map[string][]string{"hdr10": {"hev1.2", "hvc1.2"}, "hevc": {"hvc"}},
+				MaxBitrate:   math.MaxInt32,
+				MinBitrate:   0,
 			},
 			"/",
 		},
@@ -38,10 +44,12 @@ func TestURLParseUrl(t *testing.T) {
 			"two video types and two audio types",
 			"/v(hdr10,hevc)/a(aac,noAd)/",
 			MediaFilters{
-				Videos:     []VideoType{"hev1.2", "hvc1.2", videoHEVC},
-				Audios:     []AudioType{audioAAC, audioNoAudioDescription},
-				MaxBitrate: math.MaxInt32,
-				MinBitrate: 0,
+				Videos:           []VideoType{"hev1.2", "hvc1.2", "hvc"},
+				Audios:           []AudioType{"mp4a"},
+				AudioDescription: AudioDescriptionExclude,
+				CodecAliases:     map[string][]string{"hdr10": {"hev1.2", "hvc1.2"}, "hevc": {"hvc"}, "aac": {"mp4a"}},
+				MaxBitrate:       math.MaxInt32,
+				MinBitrate:       0,
 			},
 			"/",
 		},
@@ -49,10 +57,11 @@ func TestURLParseUrl(t *testing.T) {
 			"videos, audio, captions and bitrate range",
 			"/v(hdr10,hevc)/a(aac)/al(pt-BR,en)/c(en)/b(100,4000)/",
 			MediaFilters{
-				Videos:           []VideoType{"hev1.2", "hvc1.2", videoHEVC},
-				Audios:           []AudioType{audioAAC},
+				Videos:           []VideoType{"hev1.2", "hvc1.2", "hvc"},
+				Audios:           []AudioType{"mp4a"},
 				AudioLanguages:   []AudioLanguage{audioLangPTBR, audioLangEN},
 				CaptionLanguages: []CaptionLanguage{captionEN},
+				CodecAliases:     map[string][]string{"hdr10": {"hev1.2", "hvc1.2"}, "hevc": {"hvc"}, "aac": {"mp4a"}},
 				MaxBitrate:       4000,
 				MinBitrate:       100,
 			},
@@ -67,6 +76,15 @@ func TestURLParseUrl(t *testing.T) {
 			},
 			"/",
 		},
+		{
+			"bitrate range with a single minimum bitrate value",
+			"/b(500)/",
+			MediaFilters{
+				MaxBitrate: math.MaxInt32,
+				MinBitrate: 500,
+			},
+			"/",
+		},
 		{
 			"bitrate range with maximum bitrate only",
 			"/b(,3000)/",
@@ -76,6 +94,222 @@ func TestURLParseUrl(t *testing.T) {
 			},
 			"/",
 		},
+		{
+			"resolution range",
+			"/r(480,1080)/",
+			MediaFilters{
+				MinHeight:  480,
+				MaxHeight:  1080,
+				MaxBitrate: math.MaxInt32,
+				MinBitrate: 0,
+			},
+			"/",
+		},
+		{
+			"stream types",
+			"/fs(text,image)/",
+			MediaFilters{
+				FilterStreamTypes: []StreamType{streamText, streamImage},
+				MaxBitrate:        math.MaxInt32,
+				MinBitrate:        0,
+			},
+			"/",
+		},
+		{
+			"resolution range with maximum height only",
+			"/r(,1080)/",
+			MediaFilters{
+				MaxHeight:  1080,
+				MaxBitrate: math.MaxInt32,
+				MinBitrate: 0,
+			},
+			"/",
+		},
+		{
+			"resolution range with minimum height only",
+			"/r(720)/",
+			MediaFilters{
+				MinHeight:  720,
+				MaxBitrate: math.MaxInt32,
+				MinBitrate: 0,
+			},
+			"/",
+		},
+		{
+			"maximum frame rate",
+			"/fr(30)/",
+			MediaFilters{
+				MaxFrameRate: 30,
+				MaxBitrate:   math.MaxInt32,
+				MinBitrate:   0,
+			},
+			"/",
+		},
+		{
+			"maximum frame rate with decimals",
+			"/fr(29.97)/",
+			MediaFilters{
+				MaxFrameRate: 29.97,
+				MaxBitrate:   math.MaxInt32,
+				MinBitrate:   0,
+			},
+			"/",
+		},
+		{
+			"dynamic ranges",
+			"/hdr(pq,HLG)/",
+			MediaFilters{
+				DynamicRanges: []DynamicRange{DynamicRangePQ, DynamicRangeHLG},
+				MaxBitrate:    math.MaxInt32,
+				MinBitrate:    0,
+			},
+			"/",
+		},
+		{
+			"include mode dynamic ranges",
+			"/hdr(+sdr,+dovi)/",
+			MediaFilters{
+				DynamicRanges:    []DynamicRange{DynamicRangeSDR, DynamicRangeDolbyVision},
+				DynamicRangeMode: FilterModeInclude,
+				MaxBitrate:       math.MaxInt32,
+				MinBitrate:       0,
+			},
+			"/",
+		},
+		{
+			"maximum audio channels",
+			"/ch(6)/",
+			MediaFilters{
+				MaxAudioChannels: 6,
+				MaxBitrate:       math.MaxInt32,
+				MinBitrate:       0,
+			},
+			"/",
+		},
+		{
+			"stereo audio only, without joint object coding",
+			"/ch(stereo,nojoc)/",
+			MediaFilters{
+				MaxAudioChannels: 2,
+				ExcludeJOC:       true,
+				MaxBitrate:       math.MaxInt32,
+				MinBitrate:       0,
+			},
+			"/",
+		},
+		{
+			"audio description only",
+			"/ad(only)/",
+			MediaFilters{
+				AudioDescription: AudioDescriptionOnly,
+				MaxBitrate:       math.MaxInt32,
+				MinBitrate:       0,
+			},
+			"/",
+		},
+		{
+			"roles",
+			"/role(commentary,Dub)/",
+			MediaFilters{
+				Roles:      []Role{"commentary", "dub"},
+				MaxBitrate: math.MaxInt32,
+				MinBitrate: 0,
+			},
+			"/",
+		},
+		{
+			"include mode roles",
+			"/role(+main,+forced-subtitle)/",
+			MediaFilters{
+				Roles:      []Role{"main", "forced-subtitle"},
+				RoleMode:   FilterModeInclude,
+				MaxBitrate: math.MaxInt32,
+				MinBitrate: 0,
+			},
+			"/",
+		},
+		{
+			"closed captions by instream id and language",
+			"/cc(CC3,es)/",
+			MediaFilters{
+				ClosedCaptions: []ClosedCaption{"CC3", "es"},
+				MaxBitrate:     math.MaxInt32,
+				MinBitrate:     0,
+			},
+			"/",
+		},
+		{
+			"include mode closed captions",
+			"/cc(+CC1)/",
+			MediaFilters{
+				ClosedCaptions:    []ClosedCaption{"CC1"},
+				ClosedCaptionMode: FilterModeInclude,
+				MaxBitrate:        math.MaxInt32,
+				MinBitrate:        0,
+			},
+			"/",
+		},
+		{
+			"drm systems",
+			"/drm(widevine,PlayReady)/",
+			MediaFilters{
+				DRMSystems: []DRMSystem{DRMSystemWidevine, DRMSystemPlayReady},
+				MaxBitrate: math.MaxInt32,
+				MinBitrate: 0,
+			},
+			"/",
+		},
+		{
+			"time clip",
+			"/t(30,90.5)/",
+			MediaFilters{
+				Clip:       &TimeRange{Start: 30 * time.Second, End: 90500 * time.Millisecond},
+				MaxBitrate: math.MaxInt32,
+				MinBitrate: 0,
+			},
+			"/",
+		},
+		{
+			"dvr window",
+			"/dvr(1800)/",
+			MediaFilters{
+				DVRWindow:  30 * time.Minute,
+				MaxBitrate: math.MaxInt32,
+				MinBitrate: 0,
+			},
+			"/",
+		},
+		{
+			"video and audio bitrate ranges",
+			"/b(video:500,5000)/b(audio:64,192)/",
+			MediaFilters{
+				VideoBitrate: &BitrateRange{Min: 500000, Max: 5000000},
+				AudioBitrate: &BitrateRange{Min: 64000, Max: 192000},
+				MaxBitrate:   math.MaxInt32,
+				MinBitrate:   0,
+			},
+			"/",
+		},
+		{
+			"video bitrate range with minimum bitrate only",
+			"/b(video:500)/",
+			MediaFilters{
+				VideoBitrate: &BitrateRange{Min: 500000, Max: math.MaxInt32},
+				MaxBitrate:   math.MaxInt32,
+				MinBitrate:   0,
+			},
+			"/",
+		},
+		{
+			"audio bitrate range with maximum bitrate only, along with a bitrate range",
+			"/b(audio:,192)/b(100,4000)/",
+			MediaFilters{
+				AudioBitrate: &BitrateRange{Max: 192000},
+				MaxBitrate:   4000,
+				MinBitrate:   100,
+			},
+			"/",
+		},
 		{
 			"detect protocol hls for urls with .m3u8 extension",
 			"/path/here/with/master.m3u8",
@@ -99,14 +333,83 @@ func TestURLParseUrl(t *testing.T) {
 		{
 			"detect filters for propeller channels and set path properly",
 			"/v(avc)/a(aac)/propeller/orgID/master.m3u8",
+			MediaFilters{
+				Videos:       []VideoType{videoH264},
+				Audios:       []AudioType{"mp4a"},
+				CodecAliases: map[string][]string{"aac": {"mp4a"}},
+				Protocol:     ProtocolHLS,
+				MaxBitrate:   math.MaxInt32,
+				MinBitrate:   0,
+			},
+			"/propeller/orgID/master.m3u8",
+		},
+		{
+			"include mode video and caption types",
+			"/v(+avc,+hdr10)/ct(+wvtt)/",
+			MediaFilters{
+				Videos:          []VideoType{videoH264, "hev1.2", "hvc1.2"},
+				VideoMode:       FilterModeInclude,
+				CaptionTypes:    []CaptionType{"wvtt"},
+				CaptionTypeMode: FilterModeInclude,
+				CodecAliases:    map[string][]string{"hdr10": {"hev1.2", "hvc1.2"}},
+				MaxBitrate:      math.MaxInt32,
+				MinBitrate:      0,
+			},
+			"/",
+		},
+		{
+			"include mode audio along with exclude mode video",
+			"/a(+aac)/a(+ec-3)/v(hevc)/",
+			MediaFilters{
+				Videos:       []VideoType{"hvc"},
+				Audios:       []AudioType{"mp4a", audioEnhacedAC3},
+				AudioMode:    FilterModeInclude,
+				CodecAliases: map[string][]string{"aac": {"mp4a"}, "hevc": {"hvc"}},
+				MaxBitrate:   math.MaxInt32,
+				MinBitrate:   0,
+			},
+			"/",
+		},
+		{
+			"codec aliases",
+			"/v(h264,DolbyVision)/a(eac3,ac3)/ct(webvtt)/",
+			MediaFilters{
+				Videos:       []VideoType{"avc", "dvh"},
+				Audios:       []AudioType{"ec-3", "ac-3"},
+				CaptionTypes: []CaptionType{"wvtt"},
+				CodecAliases: map[string][]string{
+					"h264":        {"avc"},
+					"DolbyVision": {"dvh"},
+					"eac3":        {"ec-3"},
+					"ac3":         {"ac-3"},
+					"webvtt":      {"wvtt"},
+				},
+				MaxBitrate: math.MaxInt32,
+				MinBitrate: 0,
+			},
+			"/",
+		},
+		{
+			"video level and profile caps",
+			"/vl(avc:4.0,hevc:5.1)/vp(hevc:main,avc:High,avc:main)/",
+			MediaFilters{
+				VideoLevels:   map[codecs.Family]float64{codecs.FamilyAVC: 4, codecs.FamilyHEVC: 5.1},
+				VideoProfiles: map[codecs.Family][]string{codecs.FamilyHEVC: {"main"}, codecs.FamilyAVC: {"high", "main"}},
+				MaxBitrate:    math.MaxInt32,
+				MinBitrate:    0,
+			},
+			"/",
+		},
+		{
+			"keep path parts with parentheses that aren't filters",
+			"/v(avc)/show/episode(1).m3u8",
 			MediaFilters{
 				Videos:     []VideoType{videoH264},
-				Audios:     []AudioType{audioAAC},
 				Protocol:   ProtocolHLS,
 				MaxBitrate: math.MaxInt32,
 				MinBitrate: 0,
 			},
-			"/propeller/orgID/master.m3u8",
+			"/show/episode(1).m3u8",
 		},
 		{
 			"set path properly for propeller channel with no filters",
@@ -147,3 +450,313 @@ func TestURLParseUrl(t *testing.T) {
 		})
 	}
 }
+
+func TestURLParseInvalidFilters(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectKey   string
+		expectValue string
+	}{
+		{
+			"unknown filter",
+			"/x(1)/master.m3u8",
+			"x",
+			"1",
+		},
+		{
+			"non numeric minimum bitrate",
+			"/b(abc,1000)/master.m3u8",
+			"b",
+			"abc",
+		},
+		{
+			"non numeric maximum bitrate",
+			"/b(100,1k)/master.m3u8",
+			"b",
+			"1k",
+		},
+		{
+			"negative bitrate",
+			"/b(-100)/master.m3u8",
+			"b",
+			"-100",
+		},
+		{
+			"bitrate range with too many values",
+			"/b(1,2,3)/master.m3u8",
+			"b",
+			"1,2,3",
+		},
+		{
+			"bitrate range scoped to an unsupported content type",
+			"/b(text:1,2)/master.m3u8",
+			"b",
+			"text:1,2",
+		},
+		{
+			"non numeric content type bitrate",
+			"/b(video:low)/master.m3u8",
+			"b",
+			"video:low",
+		},
+		{
+			"bitrate range with a minimum greater than its maximum",
+			"/b(5000,500)/master.m3u8",
+			"b",
+			"5000,500",
+		},
+		{
+			"content type bitrate range with a minimum greater than its maximum",
+			"/b(video:5000,500)/master.mpd",
+			"b",
+			"video:5000,500",
+		},
+		{
+			"empty bitrate range",
+			"/b()/master.m3u8",
+			"b",
+			"",
+		},
+		{
+			"bitrate range without bounds",
+			"/b(,)/master.m3u8",
+			"b",
+			",",
+		},
+		{
+			"empty content type bitrate range",
+			"/b(video:)/master.mpd",
+			"b",
+			"video:",
+		},
+		{
+			"non numeric height",
+			"/r(0,hd)/master.m3u8",
+			"r",
+			"hd",
+		},
+		{
+			"resolution range with a minimum greater than its maximum",
+			"/r(1080,720)/master.m3u8",
+			"r",
+			"1080,720",
+		},
+		{
+			"not a number frame rate",
+			"/fr(NaN)/master.m3u8",
+			"fr",
+			"NaN",
+		},
+		{
+			"infinite frame rate",
+			"/fr(Inf)/master.m3u8",
+			"fr",
+			"Inf",
+		},
+		{
+			"non numeric frame rate",
+			"/fr(fast)/master.m3u8",
+			"fr",
+			"fast",
+		},
+		{
+			"frame rate with too many values",
+			"/fr(30,60)/master.m3u8",
+			"fr",
+			"30,60",
+		},
+		{
+			"empty codec filter",
+			"/v()/master.m3u8",
+			"v",
+			"",
+		},
+		{
+			"include and exclude values mixed in a filter",
+			"/v(+avc,hevc)/master.m3u8",
+			"v",
+			"+avc,hevc",
+		},
+		{
+			"include and exclude values mixed across filters",
+			"/a(aac)/a(+ec-3)/master.m3u8",
+			"a",
+			"+ec-3",
+		},
+		{
+			"empty include value",
+			"/ct(+)/master.m3u8",
+			"ct",
+			"+",
+		},
+		{
+			"video level cap without a codec",
+			"/vl(4.0)/master.m3u8",
+			"vl",
+			"4.0",
+		},
+		{
+			"unknown dynamic range",
+			"/hdr(hdr10)/master.m3u8",
+			"hdr",
+			"hdr10",
+		},
+		{
+			"include and exclude dynamic ranges mixed",
+			"/hdr(+sdr,pq)/master.m3u8",
+			"hdr",
+			"+sdr,pq",
+		},
+		{
+			"non numeric audio channels",
+			"/ch(atmos)/master.m3u8",
+			"ch",
+			"atmos",
+		},
+		{
+			"zero audio channels",
+			"/ch(0)/master.m3u8",
+			"ch",
+			"0",
+		},
+		{
+			"unknown audio description mode",
+			"/ad(include)/master.m3u8",
+			"ad",
+			"include",
+		},
+		{
+			"unknown role",
+			"/role(director)/master.mpd",
+			"role",
+			"director",
+		},
+		{
+			"unknown drm system",
+			"/drm(primetime)/master.mpd",
+			"drm",
+			"primetime",
+		},
+		{
+			"time clip without an end",
+			"/t(30)/master.m3u8",
+			"t",
+			"30",
+		},
+		{
+			"time clip ending before its start",
+			"/t(90,30)/master.m3u8",
+			"t",
+			"90,30",
+		},
+		{
+			"non numeric time clip",
+			"/t(0,1m)/master.m3u8",
+			"t",
+			"1m",
+		},
+		{
+			"empty dvr window",
+			"/dvr(0)/master.m3u8",
+			"dvr",
+			"0",
+		},
+		{
+			"dvr window with several values",
+			"/dvr(1800,3600)/master.m3u8",
+			"dvr",
+			"1800,3600",
+		},
+		{
+			"video level cap for an unknown codec",
+			"/vl(vvc1:4.0)/master.m3u8",
+			"vl",
+			"vvc1:4.0",
+		},
+		{
+			"non numeric video level cap",
+			"/vl(avc:high)/master.m3u8",
+			"vl",
+			"avc:high",
+		},
+		{
+			"empty video profile cap",
+			"/vp(hevc:)/master.m3u8",
+			"vp",
+			"hevc:",
+		},
+		{
+			"dvr window too long to be held by a duration",
+			"/dvr(1e300)/master.m3u8",
+			"dvr",
+			"1e300",
+		},
+		{
+			"time clip end too far to be held by a duration",
+			"/t(0,1e300)/master.m3u8",
+			"t",
+			"1e300",
+		},
+		{
+			"audio description excluded in include mode",
+			"/a(+noAd)/master.m3u8",
+			"a",
+			"+noAd",
+		},
+		{
+			"unknown stream type",
+			"/fs(video,images)/master.m3u8",
+			"fs",
+			"images",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := URLParse(test.input)
+
+			var filterErr *FilterError
+			if !errors.As(err, &filterErr) {
+				t.Fatalf("expected a *FilterError, got %v", err)
+			}
+
+			if filterErr.Key != test.expectKey || filterErr.Value != test.expectValue {
+				t.Errorf("wrong filter in error.\nwant %s(%s)\ngot %s(%s)", test.expectKey, test.expectValue,
+					filterErr.Key, filterErr.Value)
+			}
+		})
+	}
+}
+
+func TestURLParseFilterPath(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		expectFilterPath string
+	}{
+		{
+			"filters are kept in the order they were given",
+			"/a(ec-3)/b(0,5000)/show/master.m3u8",
+			"/a(ec-3)/b(0,5000)",
+		},
+		{
+			"no filters",
+			"/show/master.m3u8",
+			"",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, output, err := URLParse(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if output.FilterPath != test.expectFilterPath {
+				t.Errorf("wrong filter path.\nwant %q\ngot %q", test.expectFilterPath, output.FilterPath)
+			}
+		})
+	}
+}